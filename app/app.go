@@ -48,8 +48,16 @@ type App struct {
 	keysFile                string
 	listenAddress           string
 	jobConcurrency          int
+	gitJobConcurrency       int
+	gitHostJobConcurrency   int
+	runJobConcurrency       int
+	jobHistoryCap           int
+	jobHistoryMaxAge        time.Duration
+	failedJobHistoryMaxAge  time.Duration
 	logLevel                models.LogLevel
 	logCap                  int
+	processOutputCap        int
+	processSampleInterval   time.Duration
 	pubSubHistoryCap        int
 	periodicJobsInterval    time.Duration
 	gracefulShutdownTimeout time.Duration
@@ -69,8 +77,16 @@ func New(opts ...Opt) *App {
 		keysFile:                DefaultKeysFile,
 		listenAddress:           DefaultListenAddress,
 		jobConcurrency:          DefaultJobConcurrency,
+		gitJobConcurrency:       DefaultGitJobConcurrency,
+		gitHostJobConcurrency:   DefaultGitHostJobConcurrency,
+		runJobConcurrency:       DefaultRunJobConcurrency,
+		jobHistoryCap:           DefaultJobHistoryCap,
+		jobHistoryMaxAge:        DefaultJobHistoryMaxAge,
+		failedJobHistoryMaxAge:  DefaultFailedJobHistoryMaxAge,
 		logLevel:                DefaultLogLevel,
 		logCap:                  DefaultLogCap,
+		processOutputCap:        DefaultProcessOutputCap,
+		processSampleInterval:   DefaultProcessSampleInterval,
 		pubSubHistoryCap:        DefaultPubSubHistoryCap,
 		periodicJobsInterval:    DefaultPeriodicJobsInterval,
 		gracefulShutdownTimeout: DefaultGracefulShutdownTimeout,
@@ -95,8 +111,23 @@ func (a *App) Start(ctx context.Context) error {
 	viewerID, systemID := a.createBaseNodes(nodes)
 	subs := pubsub.New(a.pubSubHistoryCap)
 	log := models.NewLogger(nodes, subs, a.logCap, a.logLevel, systemID)
-	jobs := models.NewJobManager(a.jobConcurrency)
-	pm := models.NewProcessManager()
+	jobs := models.NewJobManager(
+		[]models.JobConcurrency{{
+			Pool:        models.JobPoolDefault,
+			Concurrency: a.jobConcurrency,
+		}, {
+			Pool:            models.JobPoolGit,
+			Concurrency:     a.gitJobConcurrency,
+			HostConcurrency: a.gitHostJobConcurrency,
+		}, {
+			Pool:        models.JobPoolRun,
+			Concurrency: a.runJobConcurrency,
+		}},
+		a.jobHistoryCap,
+		a.jobHistoryMaxAge,
+		a.failedJobHistoryMaxAge,
+	)
+	pm := models.NewProcessManager(a.processOutputCap)
 
 	sources, err := a.loadSources(nodes, subs, viewerID)
 	if err != nil {
@@ -178,12 +209,15 @@ func (a *App) Start(ctx context.Context) error {
 		gqlOptions...,
 	))
 
+	router.Handle("/terminal/{id}", terminalHandler(log, pm, a.listenAddress))
+
 	server := &http.Server{
 		Addr:    a.listenAddress,
 		Handler: router,
 	}
 
 	go jobs.Work(ctx)
+	go pm.SampleUsage(ctx, a.processSampleInterval)
 	a.startPeriodicJobs(ctx)
 	if a.enableSignalHandling {
 		go a.handleSignals(ctx, log, pm, server)
@@ -29,15 +29,42 @@ const (
 	// DefaultListenAddress is the default listen address.
 	DefaultListenAddress = ":3333"
 
-	// DefaultJobConcurrency is the default concurrency of the job manager.
+	// DefaultJobConcurrency is the default concurrency of jobs that aren't Git
+	// or run jobs.
 	DefaultJobConcurrency = 2
 
+	// DefaultGitJobConcurrency is the default concurrency of Git jobs.
+	DefaultGitJobConcurrency = 4
+
+	// DefaultGitHostJobConcurrency is the default concurrency of Git jobs
+	// connecting to the same remote host.
+	DefaultGitHostJobConcurrency = 2
+
+	// DefaultRunJobConcurrency is the default concurrency of jobs running tasks.
+	DefaultRunJobConcurrency = 2
+
+	// DefaultJobHistoryCap is the default number of finished jobs kept.
+	DefaultJobHistoryCap = 1000
+
+	// DefaultJobHistoryMaxAge is the default duration finished jobs are kept.
+	DefaultJobHistoryMaxAge = 24 * time.Hour
+
+	// DefaultFailedJobHistoryMaxAge is the default duration failed jobs are
+	// kept regardless of the job history cap. Zero treats them like other jobs.
+	DefaultFailedJobHistoryMaxAge = time.Duration(0)
+
 	// DefaultLogLevel is the default log level.
 	DefaultLogLevel = models.LogLevelInfo
 
 	// DefaultLogCap is the default capacity of the logger.
 	DefaultLogCap = 10000
 
+	// DefaultProcessOutputCap is the default number of output lines kept for each process.
+	DefaultProcessOutputCap = 1000
+
+	// DefaultProcessSampleInterval is the default interval between process usage samples.
+	DefaultProcessSampleInterval = 2 * time.Second
+
 	// DefaultPubSubHistoryCap is the default capacity of the PubSub history.
 	DefaultPubSubHistoryCap = 1000
 
@@ -116,13 +143,57 @@ func OptListenAddress(address string) Opt {
 	}
 }
 
-// OptJobConcurrency sets the concurrency of the job manager.
+// OptJobConcurrency sets the concurrency of jobs that aren't Git or run jobs.
 func OptJobConcurrency(concurrency int) Opt {
 	return func(app *App) {
 		app.jobConcurrency = concurrency
 	}
 }
 
+// OptGitJobConcurrency sets the concurrency of Git jobs.
+func OptGitJobConcurrency(concurrency int) Opt {
+	return func(app *App) {
+		app.gitJobConcurrency = concurrency
+	}
+}
+
+// OptGitHostJobConcurrency sets the concurrency of Git jobs connecting to the
+// same remote host. Zero disables the limit.
+func OptGitHostJobConcurrency(concurrency int) Opt {
+	return func(app *App) {
+		app.gitHostJobConcurrency = concurrency
+	}
+}
+
+// OptRunJobConcurrency sets the concurrency of jobs running tasks.
+func OptRunJobConcurrency(concurrency int) Opt {
+	return func(app *App) {
+		app.runJobConcurrency = concurrency
+	}
+}
+
+// OptJobHistoryCap sets the number of finished jobs kept.
+func OptJobHistoryCap(cap int) Opt {
+	return func(app *App) {
+		app.jobHistoryCap = cap
+	}
+}
+
+// OptJobHistoryMaxAge sets how long finished jobs are kept.
+func OptJobHistoryMaxAge(maxAge time.Duration) Opt {
+	return func(app *App) {
+		app.jobHistoryMaxAge = maxAge
+	}
+}
+
+// OptFailedJobHistoryMaxAge sets how long failed jobs are kept regardless of
+// the job history cap.
+func OptFailedJobHistoryMaxAge(maxAge time.Duration) Opt {
+	return func(app *App) {
+		app.failedJobHistoryMaxAge = maxAge
+	}
+}
+
 // OptLogLevel sets the minimum level for log messages.
 func OptLogLevel(level models.LogLevel) Opt {
 	return func(app *App) {
@@ -144,6 +215,21 @@ func OptLogCap(cap int) Opt {
 	}
 }
 
+// OptProcessOutputCap sets the number of output lines kept for each process.
+func OptProcessOutputCap(cap int) Opt {
+	return func(app *App) {
+		app.processOutputCap = cap
+	}
+}
+
+// OptProcessSampleInterval sets the time to wait between process usage samples.
+// Zero or less disables sampling.
+func OptProcessSampleInterval(interval time.Duration) Opt {
+	return func(app *App) {
+		app.processSampleInterval = interval
+	}
+}
+
 // OptPeriodicJobsInterval sets the time to wait between periodic jobs.
 func OptPeriodicJobsInterval(interval time.Duration) Opt {
 	return func(app *App) {
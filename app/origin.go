@@ -0,0 +1,74 @@
+// Copyright 2019 Stratumn
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+)
+
+// loopbackHosts are the hosts the app can be reached at from the machine it
+// runs on.
+var loopbackHosts = map[string]bool{
+	"localhost": true,
+	"127.0.0.1": true,
+	"::1":       true,
+}
+
+// checkOrigin returns a function that only accepts WebSocket connections from
+// pages served by the app at the given listen address, so that other pages
+// open in the browser can't control it. Requests without an origin don't come
+// from a browser and are accepted.
+func checkOrigin(listenAddress string) func(*http.Request) bool {
+	listenHost, listenPort, err := net.SplitHostPort(listenAddress)
+	if err != nil {
+		return func(*http.Request) bool { return false }
+	}
+
+	hosts := map[string]bool{}
+	for host := range loopbackHosts {
+		hosts[host] = true
+	}
+
+	// The app can be reached at any address if it listens on all interfaces,
+	// but the host of a page could then be any domain resolving to the
+	// machine, so only loopback hosts are trusted.
+	if ip := net.ParseIP(listenHost); listenHost != "" && (ip == nil || !ip.IsUnspecified()) {
+		hosts[listenHost] = true
+	}
+
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+
+		u, err := url.Parse(origin)
+		if err != nil || u.Scheme != "http" && u.Scheme != "https" {
+			return false
+		}
+
+		port := u.Port()
+		if port == "" {
+			port = "80"
+			if u.Scheme == "https" {
+				port = "443"
+			}
+		}
+
+		return port == listenPort && hosts[u.Hostname()]
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2019 Stratumn
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckOrigin(t *testing.T) {
+	tests := []struct {
+		name          string
+		listenAddress string
+		origin        string
+		want          bool
+	}{
+		{"no origin", ":3333", "", true},
+		{"localhost", ":3333", "http://localhost:3333", true},
+		{"loopback IP", ":3333", "http://127.0.0.1:3333", true},
+		{"loopback IPv6", ":3333", "http://[::1]:3333", true},
+		{"other port", ":3333", "http://localhost:8080", false},
+		{"other host", ":3333", "http://evil.example.com:3333", false},
+		{"default port", "localhost:80", "http://localhost", true},
+		{"listen host", "192.168.1.2:3333", "http://192.168.1.2:3333", true},
+		{"unspecified listen host", "0.0.0.0:3333", "http://0.0.0.0:3333", false},
+		{"other scheme", ":3333", "file://localhost:3333", false},
+		{"null", ":3333", "null", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := http.NewRequest(http.MethodGet, "/terminal/1", nil)
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+
+			assert.Equal(t, tt.want, checkOrigin(tt.listenAddress)(r))
+		})
+	}
+}
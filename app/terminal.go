@@ -0,0 +1,107 @@
+// Copyright 2019 Stratumn
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/gorilla/websocket"
+
+	"groundcontrol/models"
+)
+
+// terminalResize is a text message sent by the client to resize a terminal.
+// Binary messages contain the input of the terminal.
+type terminalResize struct {
+	Rows uint16 `json:"rows"`
+	Cols uint16 `json:"cols"`
+}
+
+// terminalHandler streams the pseudo-terminal of a process over a WebSocket.
+// The process ID is the "id" URL parameter. Only pages served by the app can
+// connect since the terminal accepts input.
+func terminalHandler(
+	log *models.Logger,
+	pm *models.ProcessManager,
+	listenAddress string,
+) http.HandlerFunc {
+	upgrader := websocket.Upgrader{
+		CheckOrigin: checkOrigin(listenAddress),
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		processID := chi.URLParam(r, "id")
+
+		terminal, err := pm.Terminal(processID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			log.WarningWithOwner(processID, "failed to open terminal because %s", err.Error())
+			return
+		}
+		defer conn.Close()
+
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+
+		output := terminal.Subscribe(ctx)
+
+		go func() {
+			defer cancel()
+
+			for {
+				messageType, message, err := conn.ReadMessage()
+				if err != nil {
+					return
+				}
+
+				switch messageType {
+				case websocket.BinaryMessage:
+					if _, err := terminal.Write(message); err != nil {
+						return
+					}
+				case websocket.TextMessage:
+					var resize terminalResize
+					if err := json.Unmarshal(message, &resize); err != nil {
+						log.WarningWithOwner(processID, "invalid terminal message because %s", err.Error())
+						continue
+					}
+
+					if err := terminal.Resize(resize.Rows, resize.Cols); err != nil {
+						log.WarningWithOwner(processID, "failed to resize terminal because %s", err.Error())
+					}
+				}
+			}
+		}()
+
+		for data := range output {
+			if err := conn.WriteMessage(websocket.BinaryMessage, data); err != nil {
+				return
+			}
+		}
+
+		conn.WriteMessage(
+			websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, "process exited"),
+		)
+	}
+}
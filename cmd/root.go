@@ -51,8 +51,16 @@ Complete documentation is available at https://github.com/stratumn/groundcontrol
 			app.OptKeysFile(viper.GetString("keys-file")),
 			app.OptListenAddress(viper.GetString("listen-address")),
 			app.OptJobConcurrency(viper.GetInt("job-concurrency")),
+			app.OptGitJobConcurrency(viper.GetInt("git-job-concurrency")),
+			app.OptGitHostJobConcurrency(viper.GetInt("git-host-job-concurrency")),
+			app.OptRunJobConcurrency(viper.GetInt("run-job-concurrency")),
+			app.OptJobHistoryCap(viper.GetInt("job-history-cap")),
+			app.OptJobHistoryMaxAge(viper.GetDuration("job-history-max-age")),
+			app.OptFailedJobHistoryMaxAge(viper.GetDuration("failed-job-history-max-age")),
 			app.OptLogLevel(models.LogLevel(strings.ToUpper(viper.GetString("log-level")))),
 			app.OptLogCap(viper.GetInt("log-cap")),
+			app.OptProcessOutputCap(viper.GetInt("process-output-cap")),
+			app.OptProcessSampleInterval(viper.GetDuration("process-sample-interval")),
 			app.OptPubSubHistoryCap(viper.GetInt("pubsub-history-cap")),
 			app.OptPeriodicJobsInterval(viper.GetDuration("periodic-jobs-interval")),
 			app.OptGracefulShutdownTimeout(viper.GetDuration("graceful-shutdown-timeout")),
@@ -86,9 +94,17 @@ func init() {
 	rootCmd.PersistentFlags().String("sources-file", app.DefaultSourcesFile, "sources config file")
 	rootCmd.PersistentFlags().String("keys-file", app.DefaultKeysFile, "keys config file")
 	rootCmd.PersistentFlags().String("listen-address", app.DefaultListenAddress, "address the server should listen on")
-	rootCmd.PersistentFlags().Int("job-concurrency", app.DefaultJobConcurrency, "how many jobs can run concurrency")
+	rootCmd.PersistentFlags().Int("job-concurrency", app.DefaultJobConcurrency, "how many jobs that aren't Git or run jobs can run concurrently")
+	rootCmd.PersistentFlags().Int("git-job-concurrency", app.DefaultGitJobConcurrency, "how many Git jobs can run concurrently")
+	rootCmd.PersistentFlags().Int("git-host-job-concurrency", app.DefaultGitHostJobConcurrency, "how many Git jobs connecting to the same remote host can run concurrently (zero means no limit)")
+	rootCmd.PersistentFlags().Int("run-job-concurrency", app.DefaultRunJobConcurrency, "how many jobs running tasks can run concurrently")
+	rootCmd.PersistentFlags().Int("job-history-cap", app.DefaultJobHistoryCap, "maximum number of finished jobs kept")
+	rootCmd.PersistentFlags().Duration("job-history-max-age", app.DefaultJobHistoryMaxAge, "how long finished jobs are kept")
+	rootCmd.PersistentFlags().Duration("failed-job-history-max-age", app.DefaultFailedJobHistoryMaxAge, "how long failed jobs are kept regardless of the job history cap (zero treats them like other jobs)")
 	rootCmd.PersistentFlags().String("log-level", app.DefaultLogLevel.String(), "minimum level of log messages (debug, info, warning, error)")
 	rootCmd.PersistentFlags().Int("log-cap", app.DefaultLogCap, "maximum number of messages the logger will keep")
+	rootCmd.PersistentFlags().Int("process-output-cap", app.DefaultProcessOutputCap, "maximum number of output lines kept for each process")
+	rootCmd.PersistentFlags().Duration("process-sample-interval", app.DefaultProcessSampleInterval, "how long to wait between process CPU and memory samples (0 to disable)")
 	rootCmd.PersistentFlags().Int("pubsub-history-cap", app.DefaultLogCap, "maximum number of messages the subscription manager will keep")
 	rootCmd.PersistentFlags().Duration("periodic-jobs-interval", app.DefaultPeriodicJobsInterval, "how long to wait between rounds of periodic jobs")
 	rootCmd.PersistentFlags().Duration("graceful-shutdown-timeout", app.DefaultGracefulShutdownTimeout, "maximum amount of time allowed to gracefully shutdown the app")
@@ -103,8 +119,16 @@ func init() {
 		"keys-file",
 		"listen-address",
 		"job-concurrency",
+		"git-job-concurrency",
+		"git-host-job-concurrency",
+		"run-job-concurrency",
+		"job-history-cap",
+		"job-history-max-age",
+		"failed-job-history-max-age",
 		"log-level",
 		"log-cap",
+		"process-output-cap",
+		"process-sample-interval",
 		"pubsub-history-cap",
 		"periodic-jobs-interval",
 		"graceful-shutdown-timeout",
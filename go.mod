@@ -7,9 +7,11 @@ require (
 	github.com/asticode/go-astilectron v0.8.0
 	github.com/go-chi/chi v4.0.1+incompatible
 	github.com/gorilla/websocket v1.4.0
+	github.com/kr/pty v1.1.4
 	github.com/mitchellh/go-homedir v1.1.0
 	github.com/pkg/browser v0.0.0-20180916011732-0a3d74bf9ce4
 	github.com/rs/cors v1.6.0
+	github.com/sergi/go-diff v1.0.0
 	github.com/spf13/cobra v0.0.3
 	github.com/spf13/viper v1.3.1
 	github.com/stretchr/testify v1.3.0
@@ -0,0 +1,69 @@
+// Copyright 2019 Stratumn
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"context"
+	"strings"
+
+	"gopkg.in/src-d/go-git.v4/plumbing/transport"
+
+	"groundcontrol/models"
+)
+
+// projectAuth returns the Git authentication method of a project.
+func projectAuth(ctx context.Context, project models.Project) (transport.AuthMethod, error) {
+	modelCtx := models.GetModelContext(ctx)
+	workspace := project.Workspace(ctx)
+
+	return models.ResolveGitAuth(
+		modelCtx.Keys,
+		project.Repository,
+		workspace.Credentials,
+		modelCtx.Sources.Credentials,
+	)
+}
+
+// sourceAuth returns the Git authentication method of a Git source.
+func sourceAuth(ctx context.Context, source models.GitSource) (transport.AuthMethod, error) {
+	modelCtx := models.GetModelContext(ctx)
+
+	return models.ResolveGitAuth(
+		modelCtx.Keys,
+		source.Repository,
+		modelCtx.Sources.Credentials,
+	)
+}
+
+// checkAuth replaces errors caused by rejected credentials with ErrAuth.
+// The original error is logged.
+func checkAuth(ctx context.Context, ownerID string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	switch err {
+	case transport.ErrAuthenticationRequired, transport.ErrAuthorizationFailed, transport.ErrInvalidAuthMethod:
+	default:
+		// SSH errors aren't exported.
+		if !strings.Contains(err.Error(), "unable to authenticate") {
+			return err
+		}
+	}
+
+	models.GetModelContext(ctx).Log.ErrorWithOwner(ownerID, "authentication failed because %s", err.Error())
+
+	return ErrAuth
+}
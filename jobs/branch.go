@@ -0,0 +1,265 @@
+// Copyright 2019 Stratumn
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"context"
+	"strings"
+
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/config"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+
+	"groundcontrol/models"
+)
+
+// CreateBranch creates a branch starting at the current head of a project and checks it out.
+func CreateBranch(ctx context.Context, projectID string, name string, priority models.JobPriority) (string, error) {
+	if !isValidBranchName(name) {
+		return "", ErrBranchName
+	}
+
+	return addCheckoutJob(ctx, projectID, CreateBranchJob, priority, func(_ context.Context, repo *git.Repository) error {
+		return doCreateBranch(repo, name)
+	})
+}
+
+// Checkout checks out an existing branch in a project.
+// If the branch only exists on the remote, a local branch tracking it is created.
+func Checkout(ctx context.Context, projectID string, name string, priority models.JobPriority) (string, error) {
+	if !isValidBranchName(name) {
+		return "", ErrBranchName
+	}
+
+	return addCheckoutJob(ctx, projectID, CheckoutJob, priority, func(_ context.Context, repo *git.Repository) error {
+		return doCheckout(repo, name)
+	})
+}
+
+// isValidBranchName returns whether a branch name is valid, rejecting
+// characters Git doesn't allow in references.
+func isValidBranchName(name string) bool {
+	return name != "" &&
+		!strings.ContainsAny(name, " \t\n~^:?*[\\") &&
+		!strings.Contains(name, "..")
+}
+
+// addCheckoutJob adds a job that changes the checked out commit of a project.
+func addCheckoutJob(
+	ctx context.Context,
+	projectID string,
+	jobName string,
+	priority models.JobPriority,
+	fn func(ctx context.Context, repo *git.Repository) error,
+) (string, error) {
+	modelCtx := models.GetModelContext(ctx)
+	nodes := modelCtx.Nodes
+	subs := modelCtx.Subs
+	workspaceID := ""
+
+	err := nodes.LockProjectE(projectID, func(project models.Project) error {
+		if !project.IsCloned(ctx) {
+			return ErrNotCloned
+		}
+
+		if project.IsCheckingOut {
+			return ErrDuplicate
+		}
+
+		workspaceID = project.WorkspaceID
+		project.IsCheckingOut = true
+		nodes.MustStoreProject(project)
+
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	subs.Publish(models.ProjectUpserted, projectID)
+	subs.Publish(models.WorkspaceUpserted, workspaceID)
+
+	jobID := modelCtx.Jobs.Add(
+		models.GetModelContext(ctx),
+		jobName,
+		projectID,
+		models.JobPoolGit,
+		"",
+		priority,
+		nil,
+		func(ctx context.Context, _ *models.ProgressReporter) error {
+			return doCheckoutJob(ctx, projectID, workspaceID, fn)
+		},
+	)
+
+	return jobID, nil
+}
+
+func doCheckoutJob(
+	ctx context.Context,
+	projectID string,
+	workspaceID string,
+	fn func(ctx context.Context, repo *git.Repository) error,
+) error {
+	modelCtx := models.GetModelContext(ctx)
+	nodes := modelCtx.Nodes
+	subs := modelCtx.Subs
+
+	defer func() {
+		nodes.MustLockProject(projectID, func(project models.Project) {
+			project.IsCheckingOut = false
+			nodes.MustStoreProject(project)
+		})
+
+		subs.Publish(models.ProjectUpserted, projectID)
+		subs.Publish(models.WorkspaceUpserted, workspaceID)
+	}()
+
+	project := nodes.MustLoadProject(projectID)
+
+	repo, err := openProject(ctx, project)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(ctx, repo); err != nil {
+		return err
+	}
+
+	return updateWorktreeStatus(ctx, repo, projectID)
+}
+
+func doCreateBranch(repo *git.Repository, name string) error {
+	refName := plumbing.NewBranchReferenceName(name)
+
+	if _, err := repo.Reference(refName, false); err == nil {
+		return ErrBranchExists
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return err
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(refName, head.Hash())); err != nil {
+		return err
+	}
+
+	if err := worktree.Checkout(&git.CheckoutOptions{Branch: refName}); err != nil {
+		// Don't leave a branch behind if it couldn't be checked out.
+		repo.Storer.RemoveReference(refName)
+		return err
+	}
+
+	return nil
+}
+
+func doCheckout(repo *git.Repository, name string) error {
+	refName := plumbing.NewBranchReferenceName(name)
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+
+	if _, err := repo.Reference(refName, false); err == nil {
+		return worktree.Checkout(&git.CheckoutOptions{Branch: refName})
+	}
+
+	remoteRef, err := repo.Reference(plumbing.NewRemoteReferenceName("origin", name), true)
+	if err != nil {
+		return ErrBranchNotFound
+	}
+
+	err = worktree.Checkout(&git.CheckoutOptions{
+		Branch: refName,
+		Hash:   remoteRef.Hash(),
+		Create: true,
+	})
+	if err != nil {
+		// go-git creates the branch before checking the worktree, so don't
+		// leave it behind if it couldn't be checked out.
+		repo.Storer.RemoveReference(refName)
+		return err
+	}
+
+	err = repo.CreateBranch(&config.Branch{
+		Name:   name,
+		Remote: "origin",
+		Merge:  refName,
+	})
+	if err == git.ErrBranchExists {
+		return nil
+	}
+
+	return err
+}
+
+// headBranch returns the name of the branch checked out in a repository.
+func headBranch(repo *git.Repository) (string, error) {
+	head, err := repo.Head()
+	if err != nil {
+		return "", err
+	}
+
+	if !head.Name().IsBranch() {
+		return "", ErrDetached
+	}
+
+	return head.Name().Short(), nil
+}
+
+// upstreamBranch returns the name of the branch of origin a local branch
+// tracks. Branches without tracking configuration track the branch of origin
+// with the same name.
+func upstreamBranch(repo *git.Repository, name string) string {
+	branch, err := repo.Branch(name)
+	if err == nil && branch.Remote == "origin" && branch.Merge.IsBranch() {
+		return branch.Merge.Short()
+	}
+
+	return name
+}
+
+// compareRefs returns the hash of the head of a project and the name of the
+// remote-tracking branch it should be compared to. It is the upstream of the
+// checked out branch, or the configured branch if the head is detached or if
+// the upstream doesn't exist yet.
+func compareRefs(
+	repo *git.Repository,
+	project models.Project,
+) (plumbing.Hash, plumbing.ReferenceName, error) {
+	head, err := repo.Head()
+	if err != nil {
+		return plumbing.ZeroHash, "", err
+	}
+
+	remoteRefName := plumbing.NewRemoteReferenceName("origin", project.Branch)
+
+	if head.Name().IsBranch() {
+		upstream := plumbing.NewRemoteReferenceName("origin", upstreamBranch(repo, head.Name().Short()))
+
+		if _, err := repo.Reference(upstream, true); err == nil {
+			remoteRefName = upstream
+		}
+	}
+
+	return head.Hash(), remoteRefName, nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2019 Stratumn
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+func TestDoCheckout_dirty(t *testing.T) {
+	dir, err := ioutil.TempDir("", "groundcontrol")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(t, err)
+
+	worktree, err := repo.Worktree()
+	assert.NoError(t, err)
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "file"), []byte("a\n"), 0644))
+	_, err = worktree.Add("file")
+	assert.NoError(t, err)
+
+	hash, err := worktree.Commit("initial", &git.CommitOptions{
+		Author: &object.Signature{Name: "test", When: time.Now()},
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, repo.Storer.SetReference(plumbing.NewHashReference(
+		plumbing.NewRemoteReferenceName("origin", "feature"),
+		hash,
+	)))
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "file"), []byte("b\n"), 0644))
+
+	assert.Equal(t, git.ErrUnstagedChanges, doCheckout(repo, "feature"))
+
+	_, err = repo.Reference(plumbing.NewBranchReferenceName("feature"), false)
+	assert.Equal(t, plumbing.ErrReferenceNotFound, err)
+}
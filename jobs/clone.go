@@ -16,8 +16,11 @@ package jobs
 
 import (
 	"context"
+	"fmt"
+	"os"
 
 	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/config"
 	"gopkg.in/src-d/go-git.v4/plumbing"
 
 	"groundcontrol/models"
@@ -29,6 +32,7 @@ func Clone(ctx context.Context, projectID string, priority models.JobPriority) (
 	nodes := modelCtx.Nodes
 	subs := modelCtx.Subs
 	workspaceID := ""
+	host := ""
 
 	err := nodes.LockProjectE(projectID, func(project models.Project) error {
 		if project.IsCloning {
@@ -36,6 +40,7 @@ func Clone(ctx context.Context, projectID string, priority models.JobPriority) (
 		}
 
 		workspaceID = project.WorkspaceID
+		host = remoteHost(project.Repository)
 		project.IsCloning = true
 		nodes.MustStoreProject(project)
 
@@ -52,16 +57,24 @@ func Clone(ctx context.Context, projectID string, priority models.JobPriority) (
 		models.GetModelContext(ctx),
 		CloneJob,
 		projectID,
+		models.JobPoolGit,
+		host,
 		priority,
-		func(ctx context.Context) error {
-			return doClone(ctx, projectID, workspaceID)
+		gitRetryPolicy,
+		func(ctx context.Context, progress *models.ProgressReporter) error {
+			return doClone(ctx, projectID, workspaceID, progress)
 		},
 	)
 
 	return jobID, nil
 }
 
-func doClone(ctx context.Context, projectID string, workspaceID string) error {
+func doClone(
+	ctx context.Context,
+	projectID string,
+	workspaceID string,
+	progress *models.ProgressReporter,
+) error {
 	modelCtx := models.GetModelContext(ctx)
 	nodes := modelCtx.Nodes
 	subs := modelCtx.Subs
@@ -83,16 +96,163 @@ func doClone(ctx context.Context, projectID string, workspaceID string) error {
 
 	workspace := project.Workspace(ctx)
 	directory := modelCtx.GetProjectPath(workspace.Slug, project.Repository, project.Branch)
+	cacheDir := modelCtx.GetProjectCachePath(workspace.Slug, project.Repository, project.Branch)
 
-	_, err := git.PlainCloneContext(
+	if exists(cacheDir) && !exists(directory) {
+		err := cloneFromCache(ctx, directory, cacheDir, project, progress)
+		if err == nil {
+			return setCurrentBranch(ctx, projectID)
+		}
+
+		modelCtx.Log.WarningWithOwner(projectID, "failed to clone from cache because %s", err.Error())
+
+		if err := os.RemoveAll(directory); err != nil {
+			return err
+		}
+	}
+
+	auth, err := projectAuth(ctx, project)
+	if err != nil {
+		return err
+	}
+
+	_, err = git.PlainCloneContext(
 		ctx,
 		directory,
 		false,
 		&git.CloneOptions{
-			URL:           project.Repository,
-			ReferenceName: plumbing.NewBranchReferenceName(project.Branch),
+			URL:               project.Repository,
+			Auth:              auth,
+			ReferenceName:     plumbing.NewBranchReferenceName(project.Branch),
+			Depth:             project.Depth,
+			RecurseSubmodules: recurseSubmodules(project),
+			Progress:          progress,
 		},
 	)
 
-	return err
+	if err != nil {
+		return checkAuth(ctx, projectID, err)
+	}
+
+	return setCurrentBranch(ctx, projectID)
+}
+
+// cloneFromCache clones a project using the objects of the bare repository
+// used to load commits, then sets origin to the remote repository.
+// The remote branches of the cache become the remote branches of the clone.
+func cloneFromCache(
+	ctx context.Context,
+	directory string,
+	cacheDir string,
+	project models.Project,
+	progress *models.ProgressReporter,
+) error {
+	repo, err := git.PlainInit(directory, false)
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.CreateRemote(&config.RemoteConfig{
+		Name:  "origin",
+		URLs:  []string{cacheDir},
+		Fetch: []config.RefSpec{"+refs/remotes/origin/*:refs/remotes/origin/*"},
+	})
+	if err != nil {
+		return err
+	}
+
+	err = repo.FetchContext(ctx, &git.FetchOptions{
+		RemoteName: "origin",
+		Depth:      project.Depth,
+		Progress:   progress,
+	})
+	if err != nil {
+		return err
+	}
+
+	refName := plumbing.NewBranchReferenceName(project.Branch)
+
+	remoteRef, err := repo.Reference(plumbing.NewRemoteReferenceName("origin", project.Branch), true)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := repo.Config()
+	if err != nil {
+		return err
+	}
+
+	cfg.Remotes["origin"] = &config.RemoteConfig{
+		Name:  "origin",
+		URLs:  []string{project.Repository},
+		Fetch: []config.RefSpec{config.RefSpec(fmt.Sprintf(config.DefaultFetchRefSpec, "origin"))},
+	}
+
+	cfg.Branches[project.Branch] = &config.Branch{
+		Name:   project.Branch,
+		Remote: "origin",
+		Merge:  refName,
+	}
+
+	if err := repo.Storer.SetConfig(cfg); err != nil {
+		return err
+	}
+
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(refName, remoteRef.Hash())); err != nil {
+		return err
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+
+	if err := worktree.Checkout(&git.CheckoutOptions{Branch: refName, Force: true}); err != nil {
+		return err
+	}
+
+	if project.RecurseSubmodules {
+		auth, err := projectAuth(ctx, project)
+		if err != nil {
+			return err
+		}
+
+		submodules, err := worktree.Submodules()
+		if err != nil {
+			return err
+		}
+
+		err = submodules.UpdateContext(ctx, &git.SubmoduleUpdateOptions{
+			Init:              true,
+			RecurseSubmodules: recurseSubmodules(project),
+			Auth:              auth,
+		})
+		if err != nil {
+			return checkAuth(ctx, project.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// recurseSubmodules returns how deep submodules of a project are cloned and
+// updated.
+func recurseSubmodules(project models.Project) git.SubmoduleRescursivity {
+	if project.RecurseSubmodules {
+		return git.DefaultSubmoduleRecursionDepth
+	}
+
+	return git.NoRecurseSubmodules
+}
+
+// setCurrentBranch sets the current branch of a freshly cloned project.
+func setCurrentBranch(ctx context.Context, projectID string) error {
+	nodes := models.GetModelContext(ctx).Nodes
+
+	nodes.MustLockProject(projectID, func(project models.Project) {
+		project.CurrentBranch = &project.Branch
+		nodes.MustStoreProject(project)
+	})
+
+	return nil
 }
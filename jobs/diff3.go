@@ -0,0 +1,171 @@
+// Copyright 2019 Stratumn
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"gopkg.in/src-d/go-git.v4/utils/diff"
+)
+
+// hunk replaces the lines of a base text from start to end (excluded).
+type hunk struct {
+	start int
+	end   int
+	lines []string
+}
+
+// mergeLines does a line-level three-way merge of texts. It returns false if
+// both sides changed the same or adjacent lines differently, like Git.
+func mergeLines(base, ours, theirs string) (string, bool) {
+	baseLines := splitLines(base)
+	ourHunks := diffHunks(base, ours)
+	theirHunks := diffHunks(base, theirs)
+
+	var merged []string
+	pos := 0
+
+	for len(ourHunks) > 0 || len(theirHunks) > 0 {
+		// Group the hunks of both sides that overlap or touch.
+		var ourGroup, theirGroup []hunk
+
+		if len(theirHunks) == 0 || len(ourHunks) > 0 && ourHunks[0].start <= theirHunks[0].start {
+			ourGroup, ourHunks = append(ourGroup, ourHunks[0]), ourHunks[1:]
+		} else {
+			theirGroup, theirHunks = append(theirGroup, theirHunks[0]), theirHunks[1:]
+		}
+
+		start, end := groupRange(ourGroup, theirGroup)
+
+		for {
+			if len(ourHunks) > 0 && ourHunks[0].start <= end {
+				ourGroup, ourHunks = append(ourGroup, ourHunks[0]), ourHunks[1:]
+			} else if len(theirHunks) > 0 && theirHunks[0].start <= end {
+				theirGroup, theirHunks = append(theirGroup, theirHunks[0]), theirHunks[1:]
+			} else {
+				break
+			}
+
+			_, end = groupRange(ourGroup, theirGroup)
+		}
+
+		merged = append(merged, baseLines[pos:start]...)
+		pos = end
+
+		ourLines := applyHunks(baseLines, start, end, ourGroup)
+		theirLines := applyHunks(baseLines, start, end, theirGroup)
+
+		switch {
+		case len(theirGroup) == 0:
+			merged = append(merged, ourLines...)
+		case len(ourGroup) == 0:
+			merged = append(merged, theirLines...)
+		case strings.Join(ourLines, "") == strings.Join(theirLines, ""):
+			merged = append(merged, ourLines...)
+		default:
+			return "", false
+		}
+	}
+
+	merged = append(merged, baseLines[pos:]...)
+
+	return strings.Join(merged, ""), true
+}
+
+// diffHunks returns the hunks turning a base text into another text ordered by
+// position.
+func diffHunks(base, other string) []hunk {
+	var hunks []hunk
+
+	pos := 0
+
+	for _, d := range diff.Do(base, other) {
+		lines := splitLines(d.Text)
+
+		switch d.Type {
+		case diffmatchpatch.DiffEqual:
+			pos += len(lines)
+			continue
+		case diffmatchpatch.DiffDelete:
+			if len(hunks) == 0 || hunks[len(hunks)-1].end != pos {
+				hunks = append(hunks, hunk{start: pos, end: pos})
+			}
+
+			pos += len(lines)
+			hunks[len(hunks)-1].end = pos
+		case diffmatchpatch.DiffInsert:
+			if len(hunks) == 0 || hunks[len(hunks)-1].end != pos {
+				hunks = append(hunks, hunk{start: pos, end: pos})
+			}
+
+			last := &hunks[len(hunks)-1]
+			last.lines = append(last.lines, lines...)
+		}
+	}
+
+	return hunks
+}
+
+// groupRange returns the range of the base text covered by groups of hunks.
+func groupRange(groups ...[]hunk) (int, int) {
+	start, end := -1, -1
+
+	for _, group := range groups {
+		for _, h := range group {
+			if start < 0 || h.start < start {
+				start = h.start
+			}
+
+			if h.end > end {
+				end = h.end
+			}
+		}
+	}
+
+	return start, end
+}
+
+// applyHunks returns the lines of a base text from start to end after
+// replacing them according to the hunks, which must be within the range.
+func applyHunks(baseLines []string, start, end int, hunks []hunk) []string {
+	var lines []string
+
+	pos := start
+
+	for _, h := range hunks {
+		lines = append(lines, baseLines[pos:h.start]...)
+		lines = append(lines, h.lines...)
+		pos = h.end
+	}
+
+	return append(lines, baseLines[pos:end]...)
+}
+
+// splitLines splits a text into lines ending with a line feed, except for the
+// last line if the text doesn't end with one.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+
+	lines := strings.SplitAfter(text, "\n")
+
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
@@ -14,11 +14,50 @@
 
 package jobs
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
 
 // Errors.
 var (
 	ErrDuplicate = errors.New("a job already exists")
 	ErrCloned    = errors.New("project is already cloned")
 	ErrNotCloned = errors.New("project isn't cloned")
+	ErrTimeout   = errors.New("command timed out")
+	ErrAuth      = errors.New("authentication failed for Git remote")
+
+	ErrBranchName     = errors.New("invalid branch name")
+	ErrBranchExists   = errors.New("branch already exists")
+	ErrBranchNotFound = errors.New("branch not found")
+	ErrDetached       = errors.New("head is detached, check out a branch first")
+	ErrNoUpstream     = errors.New("branch doesn't exist on the remote, push it first")
+
+	ErrDirty = errors.New("project has uncommitted changes")
+
+	ErrNotFastForward = errors.New("branch has diverged from the remote branch, pull with the rebase or merge strategy")
+	ErrRebaseMerge    = errors.New("local commits contain a merge, pull with the merge strategy")
 )
+
+// RejectedError is returned when the remote refuses to update some refs during a push.
+type RejectedError struct {
+	Refs   []string
+	Reason string
+}
+
+// Error implements the error interface.
+func (e RejectedError) Error() string {
+	return fmt.Sprintf("push rejected for %s: %s", strings.Join(e.Refs, ", "), e.Reason)
+}
+
+// ConflictError is returned when a pull can't complete because files have
+// conflicting local and remote changes.
+type ConflictError struct {
+	Files []string
+}
+
+// Error implements the error interface.
+func (e ConflictError) Error() string {
+	return fmt.Sprintf("pull has conflicts in %s", strings.Join(e.Files, ", "))
+}
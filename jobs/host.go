@@ -0,0 +1,33 @@
+// Copyright 2019 Stratumn
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"strings"
+
+	"gopkg.in/src-d/go-git.v4/plumbing/transport"
+)
+
+// remoteHost returns the host of a Git repository URL, used to limit how many
+// jobs connect to the same host concurrently. It returns an empty string for
+// local repositories.
+func remoteHost(repository string) string {
+	endpoint, err := transport.NewEndpoint(repository)
+	if err != nil {
+		return ""
+	}
+
+	return strings.ToLower(endpoint.Host)
+}
@@ -21,5 +21,9 @@ const (
 	LoadCommitsJob         = "Load Commits"
 	CloneJob               = "Clone"
 	PullJob                = "Pull"
+	PushJob                = "Push"
+	CreateBranchJob        = "Create Branch"
+	CheckoutJob            = "Checkout"
+	RestoreSnapshotJob     = "Restore Snapshot"
 	RunJob                 = "Run"
 )
@@ -15,15 +15,14 @@
 package jobs
 
 import (
-	"bytes"
 	"context"
 	"os"
+	"sort"
 	"strings"
 
 	git "gopkg.in/src-d/go-git.v4"
 	"gopkg.in/src-d/go-git.v4/plumbing"
 	"gopkg.in/src-d/go-git.v4/plumbing/object"
-	"gopkg.in/src-d/go-git.v4/plumbing/storer"
 
 	"groundcontrol/models"
 	"groundcontrol/relay"
@@ -35,6 +34,7 @@ func LoadCommits(ctx context.Context, projectID string, priority models.JobPrior
 	nodes := modelCtx.Nodes
 	subs := modelCtx.Subs
 	workspaceID := ""
+	host := ""
 
 	err := nodes.LockProjectE(projectID, func(project models.Project) error {
 		if project.IsLoadingCommits {
@@ -42,6 +42,7 @@ func LoadCommits(ctx context.Context, projectID string, priority models.JobPrior
 		}
 
 		workspaceID = project.WorkspaceID
+		host = remoteHost(project.Repository)
 		project.IsLoadingCommits = true
 		nodes.MustStoreProject(project)
 
@@ -58,16 +59,24 @@ func LoadCommits(ctx context.Context, projectID string, priority models.JobPrior
 		models.GetModelContext(ctx),
 		LoadCommitsJob,
 		projectID,
+		models.JobPoolGit,
+		host,
 		priority,
-		func(ctx context.Context) error {
-			return doLoadCommits(ctx, projectID, workspaceID)
+		gitRetryPolicy,
+		func(ctx context.Context, progress *models.ProgressReporter) error {
+			return doLoadCommits(ctx, projectID, workspaceID, progress)
 		},
 	)
 
 	return jobID, nil
 }
 
-func doLoadCommits(ctx context.Context, projectID string, workspaceID string) error {
+func doLoadCommits(
+	ctx context.Context,
+	projectID string,
+	workspaceID string,
+	progress *models.ProgressReporter,
+) error {
 	modelCtx := models.GetModelContext(ctx)
 	nodes := modelCtx.Nodes
 	subs := modelCtx.Subs
@@ -89,12 +98,20 @@ func doLoadCommits(ctx context.Context, projectID string, workspaceID string) er
 
 	project := nodes.MustLoadProject(projectID)
 
-	repo, err := cloneOrFetch(ctx, projectID)
+	repo, err := cloneOrFetch(ctx, projectID, progress)
 	if err != nil && err != git.NoErrAlreadyUpToDate {
 		return err
 	}
 
 	refName := plumbing.NewRemoteReferenceName("origin", project.Branch)
+
+	// Load the commits of the branch tracked by the checked out branch.
+	if project.IsCloned(ctx) {
+		if _, refName, err = compareRefs(repo, project); err != nil {
+			return err
+		}
+	}
+
 	ref, err := repo.Reference(refName, true)
 	if err != nil {
 		return err
@@ -106,13 +123,21 @@ func doLoadCommits(ctx context.Context, projectID string, workspaceID string) er
 	}
 
 	if project.IsCloned(ctx) {
-		return updateStatus(ctx, repo, projectID)
+		if err := updateStatus(ctx, repo, projectID); err != nil {
+			return err
+		}
+
+		return updateWorktreeStatus(ctx, repo, projectID)
 	}
 
 	return nil
 }
 
-func cloneOrFetch(ctx context.Context, projectID string) (repo *git.Repository, err error) {
+func cloneOrFetch(
+	ctx context.Context,
+	projectID string,
+	progress *models.ProgressReporter,
+) (repo *git.Repository, err error) {
 	modelCtx := models.GetModelContext(ctx)
 	project := modelCtx.Nodes.MustLoadProject(projectID)
 	workspace := project.Workspace(ctx)
@@ -120,6 +145,15 @@ func cloneOrFetch(ctx context.Context, projectID string) (repo *git.Repository,
 	cacheDir := modelCtx.GetProjectCachePath(workspace.Slug, project.Repository, project.Branch)
 	force := false
 
+	auth, err := projectAuth(ctx, project)
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		err = checkAuth(ctx, projectID, err)
+	}()
+
 	if project.IsCloned(ctx) {
 		repo, err = git.PlainOpen(projectDir)
 	} else if exists(cacheDir) {
@@ -135,7 +169,10 @@ func cloneOrFetch(ctx context.Context, projectID string) (repo *git.Repository,
 		err = repo.FetchContext(
 			ctx,
 			&git.FetchOptions{
-				Force: force,
+				Auth:     auth,
+				Force:    force,
+				Depth:    project.Depth,
+				Progress: progress,
 			},
 		)
 	} else {
@@ -145,7 +182,10 @@ func cloneOrFetch(ctx context.Context, projectID string) (repo *git.Repository,
 			true,
 			&git.CloneOptions{
 				URL:           project.Repository,
+				Auth:          auth,
 				ReferenceName: plumbing.NewBranchReferenceName(project.Branch),
+				Depth:         project.Depth,
+				Progress:      progress,
 			},
 		)
 	}
@@ -156,12 +196,7 @@ func cloneOrFetch(ctx context.Context, projectID string) (repo *git.Repository,
 func getCommits(ctx context.Context, repo *git.Repository, ref *plumbing.Reference) ([]string, error) {
 	var commitIDs []string
 
-	modelCtx := models.GetModelContext(ctx)
-
-	iter, err := repo.Log(&git.LogOptions{
-		From:  ref.Hash(),
-		Order: git.LogOrderCommitterTime,
-	})
+	iter, err := logCommits(repo, ref.Hash())
 	if err != nil {
 		return nil, err
 	}
@@ -173,79 +208,176 @@ func getCommits(ctx context.Context, repo *git.Repository, ref *plumbing.Referen
 		default:
 		}
 
-		commit := models.Commit{
-			ID:       relay.EncodeID(models.NodeTypeCommit, c.Hash.String()),
-			Hash:     models.Hash(c.Hash.String()),
-			Headline: strings.Split(c.Message, "\n")[0],
-			Message:  c.Message,
-			Author:   c.Author.Name,
-			Date:     models.DateTime(c.Author.When),
-		}
-
-		modelCtx.Nodes.MustStoreCommit(commit)
-		commitIDs = append(commitIDs, commit.ID)
+		commitIDs = append(commitIDs, storeCommit(ctx, c))
 
 		return nil
 	})
 }
 
-func updateStatus(ctx context.Context, repo *git.Repository, projectID string) (projectError error) {
+func updateStatus(ctx context.Context, repo *git.Repository, projectID string) error {
 	modelCtx := models.GetModelContext(ctx)
 	nodes := modelCtx.Nodes
+	project := nodes.MustLoadProject(projectID)
+
+	hash, remoteRefName, err := compareRefs(repo, project)
+	if err != nil {
+		return err
+	}
+
+	remoteRef, err := repo.Reference(remoteRefName, true)
+	if err != nil {
+		return err
+	}
+
+	incoming, outgoing, err := compareCommits(ctx, repo, hash, remoteRef.Hash())
+	if err != nil {
+		return err
+	}
+
+	var incomingIDs, outgoingIDs []string
+
+	for _, c := range incoming {
+		incomingIDs = append(incomingIDs, storeCommit(ctx, c))
+	}
+
+	for _, c := range outgoing {
+		outgoingIDs = append(outgoingIDs, storeCommit(ctx, c))
+	}
 
 	nodes.MustLockProject(projectID, func(project models.Project) {
-		refName := plumbing.NewBranchReferenceName(project.Branch)
-		ref, err := repo.Reference(refName, true)
-		if err != nil {
-			projectError = err
-			return
-		}
+		project.IncomingCommitIDs = incomingIDs
+		project.OutgoingCommitIDs = outgoingIDs
+		project.BehindCount = len(incomingIDs)
+		project.AheadCount = len(outgoingIDs)
+		project.IsBehind = project.BehindCount > 0
+		project.IsAhead = project.AheadCount > 0
+		nodes.MustStoreProject(project)
+	})
 
-		remoteRefName := plumbing.NewRemoteReferenceName("origin", project.Branch)
-		remoteRef, err := repo.Reference(remoteRefName, true)
+	return nil
+}
+
+// compareCommits returns the commits reachable from the remote hash but not
+// from the local hash (incoming), and the other way around (outgoing).
+// Commits are ordered by committer time, newest first.
+func compareCommits(
+	ctx context.Context,
+	repo *git.Repository,
+	localHash plumbing.Hash,
+	remoteHash plumbing.Hash,
+) ([]*object.Commit, []*object.Commit, error) {
+	outgoing, incoming, _, err := walkToMergeBase(ctx, repo, localHash, remoteHash)
+
+	return incoming, outgoing, err
+}
+
+// logCommits returns an iterator over the commit with the given hash and its
+// ancestors ordered by committer time. The parents of the boundary commits of
+// a shallow clone are skipped since they aren't in the repository.
+func logCommits(repo *git.Repository, hash plumbing.Hash) (object.CommitIter, error) {
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	shallow, err := repo.Storer.Shallow()
+	if err != nil {
+		return nil, err
+	}
+
+	missing := map[plumbing.Hash]bool{}
+
+	for _, shallowHash := range shallow {
+		shallowCommit, err := repo.CommitObject(shallowHash)
+		if err == plumbing.ErrObjectNotFound {
+			continue
+		}
 		if err != nil {
-			projectError = err
-			return
+			return nil, err
 		}
 
-		iter, err := repo.Log(&git.LogOptions{
-			From:  ref.Hash(),
-			Order: git.LogOrderCommitterTime,
-		})
-		if err != nil {
-			projectError = err
-			return
+		for _, parentHash := range shallowCommit.ParentHashes {
+			if _, err := repo.CommitObject(parentHash); err == plumbing.ErrObjectNotFound {
+				missing[parentHash] = true
+			}
 		}
+	}
 
-		project.IsBehind = true
-		project.IsAhead = false
-		remoteHash := remoteRef.Hash()
-		last := true
+	return object.NewCommitIterCTime(commit, missing, nil), nil
+}
 
-		projectError = iter.ForEach(func(commit *object.Commit) error {
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			default:
-			}
+// storeCommit stores a Git commit as a node and returns its ID.
+func storeCommit(ctx context.Context, c *object.Commit) string {
+	commit := models.Commit{
+		ID:       relay.EncodeID(models.NodeTypeCommit, c.Hash.String()),
+		Hash:     models.Hash(c.Hash.String()),
+		Headline: strings.Split(c.Message, "\n")[0],
+		Message:  c.Message,
+		Author:   c.Author.Name,
+		Date:     models.DateTime(c.Author.When),
+	}
 
-			if bytes.Compare(remoteHash[:], commit.Hash[:]) == 0 {
-				project.IsBehind = false
-				project.IsAhead = !last
-				return storer.ErrStop
-			}
+	models.GetModelContext(ctx).Nodes.MustStoreCommit(commit)
 
-			last = false
-			return nil
-		})
-		if projectError != nil {
-			return
+	return commit.ID
+}
+
+func updateWorktreeStatus(ctx context.Context, repo *git.Repository, projectID string) error {
+	modelCtx := models.GetModelContext(ctx)
+	nodes := modelCtx.Nodes
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+
+	status, err := worktree.Status()
+	if err != nil {
+		return err
+	}
+
+	modified, untracked, staged := []string{}, []string{}, []string{}
+
+	for path, fileStatus := range status {
+		switch {
+		case fileStatus.Worktree == git.Untracked:
+			untracked = append(untracked, path)
+			continue
+		case fileStatus.Worktree != git.Unmodified:
+			modified = append(modified, path)
 		}
 
+		if fileStatus.Staging != git.Unmodified {
+			staged = append(staged, path)
+		}
+	}
+
+	sort.Strings(modified)
+	sort.Strings(untracked)
+	sort.Strings(staged)
+
+	var currentBranch *string
+
+	head, err := repo.Head()
+	if err != nil {
+		return err
+	}
+
+	if head.Name().IsBranch() {
+		name := head.Name().Short()
+		currentBranch = &name
+	}
+
+	nodes.MustLockProject(projectID, func(project models.Project) {
+		project.CurrentBranch = currentBranch
+		project.IsDirty = len(modified)+len(untracked)+len(staged) > 0
+		project.ModifiedFiles = modified
+		project.UntrackedFiles = untracked
+		project.StagedFiles = staged
 		nodes.MustStoreProject(project)
 	})
 
-	return
+	return nil
 }
 
 func exists(directory string) bool {
@@ -48,8 +48,11 @@ func LoadDirectorySource(ctx context.Context, sourceID string, priority models.J
 		models.GetModelContext(ctx),
 		LoadDirectorySourceJob,
 		sourceID,
+		models.JobPoolDefault,
+		"",
 		priority,
-		func(ctx context.Context) error {
+		nil,
+		func(ctx context.Context, _ *models.ProgressReporter) error {
 			return doLoadDirectorySource(ctx, sourceID)
 		},
 	)
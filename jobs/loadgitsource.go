@@ -28,12 +28,14 @@ func LoadGitSource(ctx context.Context, sourceID string, priority models.JobPrio
 	modelCtx := models.GetModelContext(ctx)
 	nodes := modelCtx.Nodes
 	subs := modelCtx.Subs
+	host := ""
 
 	err := nodes.LockGitSourceE(sourceID, func(source models.GitSource) error {
 		if source.IsLoading {
 			return ErrDuplicate
 		}
 
+		host = remoteHost(source.Repository)
 		source.IsLoading = true
 		nodes.MustStoreGitSource(source)
 
@@ -49,16 +51,19 @@ func LoadGitSource(ctx context.Context, sourceID string, priority models.JobPrio
 		models.GetModelContext(ctx),
 		LoadGitSourceJob,
 		sourceID,
+		models.JobPoolGit,
+		host,
 		priority,
-		func(ctx context.Context) error {
-			return doLoadGitSource(ctx, sourceID)
+		gitRetryPolicy,
+		func(ctx context.Context, progress *models.ProgressReporter) error {
+			return doLoadGitSource(ctx, sourceID, progress)
 		},
 	)
 
 	return jobID, nil
 }
 
-func doLoadGitSource(ctx context.Context, sourceID string) error {
+func doLoadGitSource(ctx context.Context, sourceID string, progress *models.ProgressReporter) error {
 	var (
 		workspaceIDs []string
 		err          error
@@ -81,7 +86,7 @@ func doLoadGitSource(ctx context.Context, sourceID string) error {
 		subs.Publish(models.SourceUpserted, sourceID)
 	}()
 
-	directory, err := cloneOrPullSource(ctx, sourceID)
+	directory, err := cloneOrPullSource(ctx, sourceID, progress)
 	if err != nil {
 		return err
 	}
@@ -91,7 +96,11 @@ func doLoadGitSource(ctx context.Context, sourceID string) error {
 	return err
 }
 
-func cloneOrPullSource(ctx context.Context, sourceID string) (string, error) {
+func cloneOrPullSource(
+	ctx context.Context,
+	sourceID string,
+	progress *models.ProgressReporter,
+) (string, error) {
 	var (
 		repo     *git.Repository
 		worktree *git.Worktree
@@ -102,6 +111,11 @@ func cloneOrPullSource(ctx context.Context, sourceID string) (string, error) {
 	source := modelCtx.Nodes.MustLoadGitSource(sourceID)
 	directory := modelCtx.GetGitSourcePath(source.Repository, source.Branch)
 
+	auth, err := sourceAuth(ctx, source)
+	if err != nil {
+		return "", err
+	}
+
 	if source.IsCloned(ctx) {
 		repo, err = git.PlainOpen(directory)
 		if err != nil {
@@ -114,7 +128,11 @@ func cloneOrPullSource(ctx context.Context, sourceID string) (string, error) {
 		if err == nil {
 			err = worktree.PullContext(
 				ctx,
-				&git.PullOptions{RemoteName: "origin"},
+				&git.PullOptions{
+					RemoteName: "origin",
+					Auth:       auth,
+					Progress:   progress,
+				},
 			)
 		}
 	} else {
@@ -124,13 +142,15 @@ func cloneOrPullSource(ctx context.Context, sourceID string) (string, error) {
 			false,
 			&git.CloneOptions{
 				URL:           source.Repository,
+				Auth:          auth,
 				ReferenceName: plumbing.NewBranchReferenceName(source.Branch),
+				Progress:      progress,
 			},
 		)
 	}
 
 	if err != nil && err != git.NoErrAlreadyUpToDate {
-		return "", err
+		return "", checkAuth(ctx, sourceID, err)
 	}
 
 	return directory, nil
@@ -0,0 +1,644 @@
+// Copyright 2019 Stratumn
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/filemode"
+	formatcfg "gopkg.in/src-d/go-git.v4/plumbing/format/config"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// go-git only supports fast-forward pulls, so merges and rebases are done here.
+// Files changed both locally and remotely are merged line by line like Git
+// does, without rename detection. Binary files are conflicts.
+
+// mergeCommits creates a merge commit of the remote commit into the local
+// commit given their merge base and returns its hash.
+func mergeCommits(
+	repo *git.Repository,
+	base *object.Commit,
+	local *object.Commit,
+	remote *object.Commit,
+	remoteName string,
+	sig object.Signature,
+) (plumbing.Hash, error) {
+	entries, err := mergeCommitEntries(repo, base, local, remote)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	treeHash, err := writeTree(repo, entries)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	return writeCommit(repo, &object.Commit{
+		Author:       sig,
+		Committer:    sig,
+		Message:      fmt.Sprintf("Merge remote-tracking branch '%s'\n", remoteName),
+		TreeHash:     treeHash,
+		ParentHashes: []plumbing.Hash{local.Hash, remote.Hash},
+	})
+}
+
+// rebaseCommits replays the local commits that aren't in the remote commit on
+// top of it, parents first, and returns the hash of the last one. It returns
+// ErrRebaseMerge if one of the local commits is a merge.
+func rebaseCommits(
+	ctx context.Context,
+	repo *git.Repository,
+	local *object.Commit,
+	remote *object.Commit,
+	sig object.Signature,
+) (plumbing.Hash, error) {
+	outgoing, _, _, err := walkToMergeBase(ctx, repo, local.Hash, remote.Hash)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	outgoingByHash := map[plumbing.Hash]*object.Commit{}
+
+	for _, commit := range outgoing {
+		if commit.NumParents() > 1 {
+			return plumbing.ZeroHash, ErrRebaseMerge
+		}
+
+		outgoingByHash[commit.Hash] = commit
+	}
+
+	// Without merges the outgoing commits form a chain from the local commit,
+	// which gives their order regardless of their timestamps.
+	var chain []*object.Commit
+
+	for commit := outgoingByHash[local.Hash]; commit != nil; {
+		chain = append(chain, commit)
+
+		if commit.NumParents() == 0 {
+			break
+		}
+
+		commit = outgoingByHash[commit.ParentHashes[0]]
+	}
+
+	current := remote
+
+	for i := len(chain) - 1; i >= 0; i-- {
+		commit := chain[i]
+
+		var parent *object.Commit
+
+		if commit.NumParents() == 1 {
+			parent, err = commit.Parent(0)
+			if err != nil {
+				return plumbing.ZeroHash, err
+			}
+		}
+
+		entries, err := mergeCommitEntries(repo, parent, current, commit)
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+
+		treeHash, err := writeTree(repo, entries)
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+
+		hash, err := writeCommit(repo, &object.Commit{
+			Author:       commit.Author,
+			Committer:    sig,
+			Message:      commit.Message,
+			TreeHash:     treeHash,
+			ParentHashes: []plumbing.Hash{current.Hash},
+		})
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+
+		current, err = repo.CommitObject(hash)
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+	}
+
+	return current.Hash, nil
+}
+
+// mergeBase returns the most recent common ancestor of two commits, or nil if
+// they don't have one.
+func mergeBase(ctx context.Context, repo *git.Repository, a, b plumbing.Hash) (*object.Commit, error) {
+	_, _, base, err := walkToMergeBase(ctx, repo, a, b)
+	return base, err
+}
+
+const (
+	reachableFromA = 1 << iota
+	reachableFromB
+	reachableFromBase
+)
+
+// walkToMergeBase walks the history of two commits by committer time until it
+// reaches their merge base, like Git does. It returns the commits reachable
+// only from a and only from b ordered newest first, and the merge base, which
+// is nil if the commits don't have one. Parents missing from a shallow clone
+// are skipped.
+func walkToMergeBase(
+	ctx context.Context,
+	repo *git.Repository,
+	a, b plumbing.Hash,
+) ([]*object.Commit, []*object.Commit, *object.Commit, error) {
+	var (
+		queue commitQueue
+		base  *object.Commit
+	)
+
+	flags := map[plumbing.Hash]int{}
+	commits := map[plumbing.Hash]*object.Commit{}
+
+	push := func(hash plumbing.Hash, f int) error {
+		if flags[hash]|f == flags[hash] {
+			return nil
+		}
+
+		commit, ok := commits[hash]
+		if !ok {
+			var err error
+
+			commit, err = repo.CommitObject(hash)
+			if err == plumbing.ErrObjectNotFound && hash != a && hash != b {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			commits[hash] = commit
+		}
+
+		flags[hash] |= f
+		heap.Push(&queue, commit)
+
+		return nil
+	}
+
+	if err := push(a, reachableFromA); err != nil {
+		return nil, nil, nil, err
+	}
+
+	if err := push(b, reachableFromB); err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Stop once every queued commit is known to be reachable from the base.
+	for queue.hasFlagless(flags, reachableFromBase) {
+		select {
+		case <-ctx.Done():
+			return nil, nil, nil, ctx.Err()
+		default:
+		}
+
+		commit := heap.Pop(&queue).(*object.Commit)
+		f := flags[commit.Hash]
+
+		if f&reachableFromBase == 0 && f&(reachableFromA|reachableFromB) == reachableFromA|reachableFromB {
+			if base == nil {
+				base = commit
+			}
+
+			f |= reachableFromBase
+			flags[commit.Hash] = f
+		}
+
+		for _, parent := range commit.ParentHashes {
+			if err := push(parent, f); err != nil {
+				return nil, nil, nil, err
+			}
+		}
+	}
+
+	var onlyA, onlyB []*object.Commit
+
+	for hash, commit := range commits {
+		switch flags[hash] {
+		case reachableFromA:
+			onlyA = append(onlyA, commit)
+		case reachableFromB:
+			onlyB = append(onlyB, commit)
+		}
+	}
+
+	for _, list := range [][]*object.Commit{onlyA, onlyB} {
+		sort.Slice(list, func(i, j int) bool {
+			return list[i].Committer.When.After(list[j].Committer.When)
+		})
+	}
+
+	return onlyA, onlyB, base, nil
+}
+
+// commitQueue is a heap of commits ordered by committer time, newest first.
+type commitQueue []*object.Commit
+
+func (q commitQueue) Len() int { return len(q) }
+
+func (q commitQueue) Less(i, j int) bool {
+	return q[i].Committer.When.After(q[j].Committer.When)
+}
+
+func (q commitQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *commitQueue) Push(x interface{}) { *q = append(*q, x.(*object.Commit)) }
+
+func (q *commitQueue) Pop() interface{} {
+	old := *q
+	commit := old[len(old)-1]
+	*q = old[:len(old)-1]
+
+	return commit
+}
+
+// hasFlagless returns whether a queued commit doesn't have the given flag.
+func (q commitQueue) hasFlagless(flags map[plumbing.Hash]int, flag int) bool {
+	for _, commit := range q {
+		if flags[commit.Hash]&flag == 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// mergeCommitEntries applies the changes from base to theirs to the files of
+// ours. A nil base has no files.
+func mergeCommitEntries(
+	repo *git.Repository,
+	base *object.Commit,
+	ours *object.Commit,
+	theirs *object.Commit,
+) (map[string]object.TreeEntry, error) {
+	baseEntries, err := treeEntries(base)
+	if err != nil {
+		return nil, err
+	}
+
+	ourEntries, err := treeEntries(ours)
+	if err != nil {
+		return nil, err
+	}
+
+	theirEntries, err := treeEntries(theirs)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, conflicts, err := mergeEntries(baseEntries, ourEntries, theirEntries, mergeBlobs(repo))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(conflicts) > 0 {
+		return nil, ConflictError{Files: conflicts}
+	}
+
+	return entries, nil
+}
+
+// fileMerger merges a file changed on both sides. The base is nil if the file
+// was added on both sides. It returns false if the changes conflict.
+type fileMerger func(base *object.TreeEntry, ours, theirs object.TreeEntry) (object.TreeEntry, bool, error)
+
+// mergeEntries does a three-way merge of files indexed by path.
+// It returns the merged files and the conflicting paths.
+func mergeEntries(
+	base map[string]object.TreeEntry,
+	ours map[string]object.TreeEntry,
+	theirs map[string]object.TreeEntry,
+	mergeFile fileMerger,
+) (map[string]object.TreeEntry, []string, error) {
+	merged := map[string]object.TreeEntry{}
+	paths := map[string]bool{}
+	var conflicts []string
+
+	for _, entries := range []map[string]object.TreeEntry{base, ours, theirs} {
+		for p := range entries {
+			paths[p] = true
+		}
+	}
+
+	for p := range paths {
+		b, inBase := base[p]
+		o, inOurs := ours[p]
+		t, inTheirs := theirs[p]
+
+		switch {
+		case sameEntry(o, inOurs, t, inTheirs), sameEntry(t, inTheirs, b, inBase):
+			if inOurs {
+				merged[p] = o
+			}
+		case sameEntry(o, inOurs, b, inBase):
+			if inTheirs {
+				merged[p] = t
+			}
+		case inOurs && inTheirs:
+			var basePtr *object.TreeEntry
+			if inBase {
+				basePtr = &b
+			}
+
+			entry, ok, err := mergeFile(basePtr, o, t)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if ok {
+				merged[p] = entry
+			} else {
+				conflicts = append(conflicts, p)
+			}
+		default:
+			conflicts = append(conflicts, p)
+		}
+	}
+
+	// A file and a directory can't have the same path.
+	dirConflicts := map[string]bool{}
+
+	for p := range merged {
+		for dir := path.Dir(p); dir != "."; dir = path.Dir(dir) {
+			if _, ok := merged[dir]; ok && !dirConflicts[dir] {
+				dirConflicts[dir] = true
+				conflicts = append(conflicts, dir)
+			}
+		}
+	}
+
+	sort.Strings(conflicts)
+
+	return merged, conflicts, nil
+}
+
+func sameEntry(a object.TreeEntry, aExists bool, b object.TreeEntry, bExists bool) bool {
+	if !aExists || !bExists {
+		return aExists == bExists
+	}
+
+	return a.Hash == b.Hash && a.Mode == b.Mode
+}
+
+// mergeBlobs returns a fileMerger that merges the lines of regular files.
+func mergeBlobs(repo *git.Repository) fileMerger {
+	return func(base *object.TreeEntry, ours, theirs object.TreeEntry) (object.TreeEntry, bool, error) {
+		var baseMode filemode.FileMode
+		if base != nil {
+			baseMode = base.Mode
+		}
+
+		mode, ok := mergeModes(baseMode, ours.Mode, theirs.Mode)
+		if !ok || mode != filemode.Regular && mode != filemode.Executable {
+			return object.TreeEntry{}, false, nil
+		}
+
+		baseContent := ""
+		if base != nil && (base.Mode == filemode.Regular || base.Mode == filemode.Executable) {
+			var err error
+			if baseContent, err = readBlob(repo, base.Hash); err != nil {
+				return object.TreeEntry{}, false, err
+			}
+		}
+
+		ourContent, err := readBlob(repo, ours.Hash)
+		if err != nil {
+			return object.TreeEntry{}, false, err
+		}
+
+		theirContent, err := readBlob(repo, theirs.Hash)
+		if err != nil {
+			return object.TreeEntry{}, false, err
+		}
+
+		for _, content := range []string{baseContent, ourContent, theirContent} {
+			if strings.IndexByte(content, 0) >= 0 {
+				return object.TreeEntry{}, false, nil
+			}
+		}
+
+		content, ok := mergeLines(baseContent, ourContent, theirContent)
+		if !ok {
+			return object.TreeEntry{}, false, nil
+		}
+
+		hash, err := writeBlob(repo, content)
+		if err != nil {
+			return object.TreeEntry{}, false, err
+		}
+
+		return object.TreeEntry{Mode: mode, Hash: hash}, true, nil
+	}
+}
+
+// mergeModes does a three-way merge of file modes.
+func mergeModes(base, ours, theirs filemode.FileMode) (filemode.FileMode, bool) {
+	switch {
+	case ours == theirs, theirs == base:
+		return ours, true
+	case ours == base:
+		return theirs, true
+	default:
+		return filemode.Empty, false
+	}
+}
+
+func readBlob(repo *git.Repository, hash plumbing.Hash) (string, error) {
+	blob, err := repo.BlobObject(hash)
+	if err != nil {
+		return "", err
+	}
+
+	r, err := blob.Reader()
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	b, err := ioutil.ReadAll(r)
+
+	return string(b), err
+}
+
+func writeBlob(repo *git.Repository, content string) (plumbing.Hash, error) {
+	obj := repo.Storer.NewEncodedObject()
+	obj.SetType(plumbing.BlobObject)
+
+	w, err := obj.Writer()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	if _, err := io.WriteString(w, content); err != nil {
+		w.Close()
+		return plumbing.ZeroHash, err
+	}
+
+	if err := w.Close(); err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	return repo.Storer.SetEncodedObject(obj)
+}
+
+// treeEntries returns the files of a commit indexed by path.
+func treeEntries(commit *object.Commit) (map[string]object.TreeEntry, error) {
+	entries := map[string]object.TreeEntry{}
+
+	if commit == nil {
+		return entries, nil
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	walker := object.NewTreeWalker(tree, true, nil)
+	defer walker.Close()
+
+	for {
+		name, entry, err := walker.Next()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if entry.Mode != filemode.Dir {
+			entries[name] = entry
+		}
+	}
+}
+
+// writeTree stores the trees containing files indexed by path and returns the
+// hash of the root tree.
+func writeTree(repo *git.Repository, entries map[string]object.TreeEntry) (plumbing.Hash, error) {
+	tree := &object.Tree{}
+	dirs := map[string]map[string]object.TreeEntry{}
+
+	for p, entry := range entries {
+		if i := strings.IndexByte(p, '/'); i >= 0 {
+			dir := p[:i]
+			if dirs[dir] == nil {
+				dirs[dir] = map[string]object.TreeEntry{}
+			}
+
+			dirs[dir][p[i+1:]] = entry
+			continue
+		}
+
+		entry.Name = p
+		tree.Entries = append(tree.Entries, entry)
+	}
+
+	for dir, dirEntries := range dirs {
+		hash, err := writeTree(repo, dirEntries)
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+
+		tree.Entries = append(tree.Entries, object.TreeEntry{
+			Name: dir,
+			Mode: filemode.Dir,
+			Hash: hash,
+		})
+	}
+
+	// Git sorts directories as if their name ended with a slash.
+	sortKey := func(entry object.TreeEntry) string {
+		if entry.Mode == filemode.Dir {
+			return entry.Name + "/"
+		}
+
+		return entry.Name
+	}
+
+	sort.Slice(tree.Entries, func(i, j int) bool {
+		return sortKey(tree.Entries[i]) < sortKey(tree.Entries[j])
+	})
+
+	obj := repo.Storer.NewEncodedObject()
+	if err := tree.Encode(obj); err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	return repo.Storer.SetEncodedObject(obj)
+}
+
+func writeCommit(repo *git.Repository, commit *object.Commit) (plumbing.Hash, error) {
+	obj := repo.Storer.NewEncodedObject()
+	if err := commit.Encode(obj); err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	return repo.Storer.SetEncodedObject(obj)
+}
+
+// signature returns the identity used for the commits created by a pull.
+// It is read from the config of the repository, then from the global Git
+// config. The fallback is used if neither sets a user.
+func signature(repo *git.Repository, fallback object.Signature) object.Signature {
+	var configs []*formatcfg.Config
+
+	if cfg, err := repo.Config(); err == nil {
+		configs = append(configs, cfg.Raw)
+	}
+
+	if home, err := os.UserHomeDir(); err == nil {
+		if f, err := os.Open(filepath.Join(home, ".gitconfig")); err == nil {
+			raw := formatcfg.New()
+			if err := formatcfg.NewDecoder(f).Decode(raw); err == nil {
+				configs = append(configs, raw)
+			}
+
+			f.Close()
+		}
+	}
+
+	sig := object.Signature{Name: fallback.Name, Email: fallback.Email, When: time.Now()}
+
+	for _, raw := range configs {
+		user := raw.Section("user")
+		name, email := user.Option("name"), user.Option("email")
+
+		if name != "" && email != "" {
+			sig.Name, sig.Email = name, email
+			break
+		}
+	}
+
+	return sig
+}
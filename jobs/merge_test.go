@@ -0,0 +1,387 @@
+// Copyright 2019 Stratumn
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/filemode"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/storage/memory"
+)
+
+func TestMergeLines(t *testing.T) {
+	base := "a\nb\nc\nd\ne\n"
+	tests := []struct {
+		name   string
+		base   string
+		ours   string
+		theirs string
+		want   string
+		wantOK bool
+	}{{
+		"unchanged",
+		base, base, base,
+		base, true,
+	}, {
+		"ours",
+		base, "a\nB\nc\nd\ne\n", base,
+		"a\nB\nc\nd\ne\n", true,
+	}, {
+		"theirs",
+		base, base, "a\nb\nc\nD\ne\n",
+		"a\nb\nc\nD\ne\n", true,
+	}, {
+		"distinct lines",
+		base, "a\nB\nc\nd\ne\n", "a\nb\nc\nD\ne\n",
+		"a\nB\nc\nD\ne\n", true,
+	}, {
+		"insertions and deletions",
+		base, "0\na\nb\nc\nd\ne\n", "a\nb\nc\nd\n",
+		"0\na\nb\nc\nd\n", true,
+	}, {
+		"same change",
+		base, "a\nB\nc\nd\ne\n", "a\nB\nc\nd\ne\n",
+		"a\nB\nc\nd\ne\n", true,
+	}, {
+		"same line",
+		base, "a\nB\nc\nd\ne\n", "a\nX\nc\nd\ne\n",
+		"", false,
+	}, {
+		"adjacent lines",
+		base, "a\nB\nc\nd\ne\n", "a\nb\nC\nd\ne\n",
+		"", false,
+	}, {
+		"insertions at same line",
+		base, "a\nX\nb\nc\nd\ne\n", "a\nY\nb\nc\nd\ne\n",
+		"", false,
+	}, {
+		"deleted and changed",
+		base, "a\nc\nd\ne\n", "a\nB\nc\nd\ne\n",
+		"", false,
+	}, {
+		"both added",
+		"", "a\n", "b\n",
+		"", false,
+	}, {
+		"no final line feed",
+		"a\nb\nc", "A\nb\nc", "a\nb\nc\nd",
+		"A\nb\nc\nd", true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := mergeLines(tt.base, tt.ours, tt.theirs)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMergeEntries(t *testing.T) {
+	file := func(hash string) object.TreeEntry {
+		return object.TreeEntry{Mode: filemode.Regular, Hash: plumbing.NewHash(hash)}
+	}
+	merged := file("ff")
+
+	// The fake merger merges files if their base isn't nil.
+	mergeFile := func(base *object.TreeEntry, ours, theirs object.TreeEntry) (object.TreeEntry, bool, error) {
+		return merged, base != nil, nil
+	}
+
+	type args struct {
+		base   map[string]object.TreeEntry
+		ours   map[string]object.TreeEntry
+		theirs map[string]object.TreeEntry
+	}
+	tests := []struct {
+		name          string
+		args          args
+		want          map[string]object.TreeEntry
+		wantConflicts []string
+	}{{
+		"unchanged",
+		args{
+			map[string]object.TreeEntry{"a": file("01")},
+			map[string]object.TreeEntry{"a": file("01")},
+			map[string]object.TreeEntry{"a": file("01")},
+		},
+		map[string]object.TreeEntry{"a": file("01")},
+		nil,
+	}, {
+		"one side changed",
+		args{
+			map[string]object.TreeEntry{"a": file("01"), "b": file("02"), "c": file("03")},
+			map[string]object.TreeEntry{"a": file("11"), "b": file("02"), "c": file("03")},
+			map[string]object.TreeEntry{"a": file("01"), "c": file("03"), "d": file("04")},
+		},
+		map[string]object.TreeEntry{"a": file("11"), "c": file("03"), "d": file("04")},
+		nil,
+	}, {
+		"same change",
+		args{
+			map[string]object.TreeEntry{"a": file("01")},
+			map[string]object.TreeEntry{"a": file("11")},
+			map[string]object.TreeEntry{"a": file("11")},
+		},
+		map[string]object.TreeEntry{"a": file("11")},
+		nil,
+	}, {
+		"both changed",
+		args{
+			map[string]object.TreeEntry{"a": file("01")},
+			map[string]object.TreeEntry{"a": file("11")},
+			map[string]object.TreeEntry{"a": file("21")},
+		},
+		map[string]object.TreeEntry{"a": merged},
+		nil,
+	}, {
+		"both added",
+		args{
+			map[string]object.TreeEntry{},
+			map[string]object.TreeEntry{"a": file("11")},
+			map[string]object.TreeEntry{"a": file("21")},
+		},
+		map[string]object.TreeEntry{},
+		[]string{"a"},
+	}, {
+		"deleted and changed",
+		args{
+			map[string]object.TreeEntry{"a": file("01"), "b": file("02")},
+			map[string]object.TreeEntry{"b": file("12")},
+			map[string]object.TreeEntry{"a": file("11")},
+		},
+		map[string]object.TreeEntry{},
+		[]string{"a", "b"},
+	}, {
+		"file and directory",
+		args{
+			map[string]object.TreeEntry{},
+			map[string]object.TreeEntry{"a": file("11")},
+			map[string]object.TreeEntry{"a/b": file("21")},
+		},
+		map[string]object.TreeEntry{"a": file("11"), "a/b": file("21")},
+		[]string{"a"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, conflicts, err := mergeEntries(tt.args.base, tt.args.ours, tt.args.theirs, mergeFile)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantConflicts, conflicts)
+		})
+	}
+}
+
+func TestWriteTree(t *testing.T) {
+	repo, err := git.Init(memory.NewStorage(), nil)
+	assert.NoError(t, err)
+
+	empty, err := writeBlob(repo, "")
+	assert.NoError(t, err)
+
+	file := object.TreeEntry{Mode: filemode.Regular, Hash: empty}
+	executable := object.TreeEntry{Mode: filemode.Executable, Hash: empty}
+
+	// Hash given by git write-tree for the same files.
+	want := plumbing.NewHash("01c06930b1fb40449fea7dc176409b3706a779cf")
+
+	got, err := writeTree(repo, map[string]object.TreeEntry{
+		"a-b": file,
+		"a.b": file,
+		"a/b": file,
+		"a0":  executable,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	tree, err := repo.TreeObject(got)
+	assert.NoError(t, err)
+
+	var names []string
+	for _, entry := range tree.Entries {
+		names = append(names, entry.Name)
+	}
+
+	assert.Equal(t, []string{"a-b", "a.b", "a", "a0"}, names)
+}
+
+func TestWalkToMergeBase(t *testing.T) {
+	repo, err := git.Init(memory.NewStorage(), nil)
+	assert.NoError(t, err)
+
+	tree, err := writeTree(repo, nil)
+	assert.NoError(t, err)
+
+	now := time.Now()
+	commit := func(minutes int, parents ...plumbing.Hash) plumbing.Hash {
+		return writeTestCommit(t, repo, tree, now.Add(time.Duration(minutes)*time.Minute), "", parents...)
+	}
+
+	root := commit(0)
+	common := commit(1, root)
+	a1 := commit(2, common)
+	b1 := commit(3, common)
+	a2 := commit(4, a1)
+	merge := commit(5, a2, b1)
+	other := commit(6)
+
+	hashes := func(commits []*object.Commit) []plumbing.Hash {
+		var hashes []plumbing.Hash
+		for _, c := range commits {
+			hashes = append(hashes, c.Hash)
+		}
+		return hashes
+	}
+
+	tests := []struct {
+		name      string
+		a         plumbing.Hash
+		b         plumbing.Hash
+		wantOnlyA []plumbing.Hash
+		wantOnlyB []plumbing.Hash
+		wantBase  plumbing.Hash
+	}{{
+		"same",
+		a2, a2,
+		nil, nil,
+		a2,
+	}, {
+		"diverged",
+		a2, b1,
+		[]plumbing.Hash{a2, a1}, []plumbing.Hash{b1},
+		common,
+	}, {
+		"ahead",
+		merge, b1,
+		[]plumbing.Hash{merge, a2, a1}, nil,
+		b1,
+	}, {
+		"behind",
+		common, a2,
+		nil, []plumbing.Hash{a2, a1},
+		common,
+	}, {
+		"unrelated",
+		other, a1,
+		[]plumbing.Hash{other}, []plumbing.Hash{a1, common, root},
+		plumbing.ZeroHash,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			onlyA, onlyB, base, err := walkToMergeBase(context.Background(), repo, tt.a, tt.b)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantOnlyA, hashes(onlyA))
+			assert.Equal(t, tt.wantOnlyB, hashes(onlyB))
+			if tt.wantBase.IsZero() {
+				assert.Nil(t, base)
+			} else if assert.NotNil(t, base) {
+				assert.Equal(t, tt.wantBase, base.Hash)
+			}
+		})
+	}
+}
+
+func TestRebaseCommits(t *testing.T) {
+	repo, err := git.Init(memory.NewStorage(), nil)
+	assert.NoError(t, err)
+
+	empty, err := writeBlob(repo, "")
+	assert.NoError(t, err)
+
+	// Each commit adds a file.
+	var trees []plumbing.Hash
+	entries := map[string]object.TreeEntry{}
+
+	for _, name := range []string{"base", "remote", "local1", "local2"} {
+		entries[name] = object.TreeEntry{Mode: filemode.Regular, Hash: empty}
+		tree, err := writeTree(repo, entries)
+		assert.NoError(t, err)
+		trees = append(trees, tree)
+		if name == "remote" {
+			delete(entries, name)
+		}
+	}
+
+	now := time.Now()
+	base := writeTestCommit(t, repo, trees[0], now, "base")
+	remote := writeTestCommit(t, repo, trees[1], now.Add(time.Minute), "remote", base)
+	// The second local commit has an older timestamp than its parent.
+	local1 := writeTestCommit(t, repo, trees[2], now.Add(2*time.Minute), "local1", base)
+	local2 := writeTestCommit(t, repo, trees[3], now.Add(-time.Hour), "local2", local1)
+
+	commit := func(hash plumbing.Hash) *object.Commit {
+		c, err := repo.CommitObject(hash)
+		assert.NoError(t, err)
+		return c
+	}
+
+	sig := object.Signature{Name: "test", When: now}
+
+	hash, err := rebaseCommits(context.Background(), repo, commit(local2), commit(remote), sig)
+	assert.NoError(t, err)
+
+	var messages []string
+	for c := commit(hash); ; {
+		messages = append(messages, c.Message)
+		if c.NumParents() == 0 {
+			break
+		}
+		c, err = c.Parent(0)
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, []string{"local2", "local1", "remote", "base"}, messages)
+
+	tree, err := commit(hash).Tree()
+	assert.NoError(t, err)
+
+	var names []string
+	for _, entry := range tree.Entries {
+		names = append(names, entry.Name)
+	}
+
+	assert.Equal(t, []string{"base", "local1", "local2", "remote"}, names)
+
+	merge := writeTestCommit(t, repo, trees[3], now.Add(3*time.Minute), "merge", local2, remote)
+
+	_, err = rebaseCommits(context.Background(), repo, commit(merge), commit(remote), sig)
+	assert.Equal(t, ErrRebaseMerge, err)
+}
+
+// writeTestCommit stores a commit and returns its hash.
+func writeTestCommit(
+	t *testing.T,
+	repo *git.Repository,
+	tree plumbing.Hash,
+	when time.Time,
+	message string,
+	parents ...plumbing.Hash,
+) plumbing.Hash {
+	sig := object.Signature{Name: "test", When: when}
+	hash, err := writeCommit(repo, &object.Commit{
+		Author:       sig,
+		Committer:    sig,
+		Message:      message,
+		TreeHash:     tree,
+		ParentHashes: parents,
+	})
+	assert.NoError(t, err)
+	return hash
+}
@@ -58,8 +58,15 @@ func StartPeriodic(
 				return
 			}
 
-			switch modelCtx.Nodes.MustLoadJob(id).Status {
-			case models.JobStatusDone, models.JobStatusFailed:
+			job, err := modelCtx.Nodes.LoadJob(id)
+			if err != nil {
+				// Only finished jobs are deleted.
+				waitGroup.Done()
+				return
+			}
+
+			switch job.Status {
+			case models.JobStatusDone, models.JobStatusFailed, models.JobStatusCanceled:
 				waitGroup.Done()
 			}
 		})
@@ -15,24 +15,28 @@
 package jobs
 
 import (
-	"bytes"
 	"context"
-	"strings"
 
 	git "gopkg.in/src-d/go-git.v4"
 	"gopkg.in/src-d/go-git.v4/plumbing"
-	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport"
 
 	"groundcontrol/models"
-	"groundcontrol/relay"
 )
 
-// Pull clones a remote repository locally and updates the project.
-func Pull(ctx context.Context, projectID string, priority models.JobPriority) (string, error) {
+// Pull pulls the checked out branch of a project from the branch it tracks.
+// If the strategy is nil, the pull strategy of the project is used.
+func Pull(
+	ctx context.Context,
+	projectID string,
+	strategy *models.PullStrategy,
+	priority models.JobPriority,
+) (string, error) {
 	modelCtx := models.GetModelContext(ctx)
 	nodes := modelCtx.Nodes
 	subs := modelCtx.Subs
 	workspaceID := ""
+	host := ""
 
 	err := nodes.LockProjectE(projectID, func(project models.Project) error {
 		if !project.IsCloned(ctx) {
@@ -43,7 +47,12 @@ func Pull(ctx context.Context, projectID string, priority models.JobPriority) (s
 			return ErrDuplicate
 		}
 
+		if strategy == nil {
+			strategy = &project.PullStrategy
+		}
+
 		workspaceID = project.WorkspaceID
+		host = remoteHost(project.Repository)
 		project.IsPulling = true
 		nodes.MustStoreProject(project)
 
@@ -60,22 +69,37 @@ func Pull(ctx context.Context, projectID string, priority models.JobPriority) (s
 		models.GetModelContext(ctx),
 		PullJob,
 		projectID,
+		models.JobPoolGit,
+		host,
 		priority,
-		func(ctx context.Context) error {
-			return doPull(ctx, projectID, workspaceID)
+		gitRetryPolicy,
+		func(ctx context.Context, progress *models.ProgressReporter) error {
+			return doPull(ctx, projectID, workspaceID, *strategy, progress)
 		},
 	)
 
 	return jobID, nil
 }
 
-func doPull(ctx context.Context, projectID string, workspaceID string) error {
+func doPull(
+	ctx context.Context,
+	projectID string,
+	workspaceID string,
+	strategy models.PullStrategy,
+	progress *models.ProgressReporter,
+) (err error) {
 	modelCtx := models.GetModelContext(ctx)
 	nodes := modelCtx.Nodes
 	subs := modelCtx.Subs
 
 	defer func() {
+		var conflicts []string
+		if conflictErr, ok := err.(ConflictError); ok {
+			conflicts = conflictErr.Files
+		}
+
 		nodes.MustLockProject(projectID, func(project models.Project) {
+			project.ConflictingFiles = conflicts
 			project.IsPulling = false
 			nodes.MustStoreProject(project)
 		})
@@ -98,78 +122,163 @@ func doPull(ctx context.Context, projectID string, workspaceID string) error {
 		return err
 	}
 
-	err = worktree.PullContext(ctx, &git.PullOptions{RemoteName: "origin"})
+	auth, err := projectAuth(ctx, project)
+	if err != nil {
+		return err
+	}
+
+	// Pull the checked out branch, which can differ from the configured branch.
+	branch, err := headBranch(repo)
+	if err != nil {
+		return err
+	}
+
+	upstream := upstreamBranch(repo, branch)
+
+	// Submodules are updated by go-git after a fast-forward.
+	err = worktree.PullContext(ctx, &git.PullOptions{
+		RemoteName:        "origin",
+		ReferenceName:     plumbing.NewBranchReferenceName(upstream),
+		Auth:              auth,
+		Depth:             project.Depth,
+		RecurseSubmodules: recurseSubmodules(project),
+		Progress:          progress,
+	})
+	if err != nil && err.Error() == errNonFastForward {
+		err = pullDiverged(ctx, repo, project, upstream, strategy, auth)
+	}
 	if err == git.NoErrAlreadyUpToDate {
 		return nil
 	}
+	if err == plumbing.ErrReferenceNotFound {
+		return ErrNoUpstream
+	}
 	if err != nil {
-		return err
+		return checkAuth(ctx, projectID, err)
 	}
 
-	refName := plumbing.NewBranchReferenceName(project.Branch)
-	ref, err := repo.Reference(refName, true)
+	ref, err := repo.Head()
 	if err != nil {
 		return err
 	}
 
-	iter, err := repo.Log(&git.LogOptions{
-		From:  ref.Hash(),
-		Order: git.LogOrderCommitterTime,
-	})
+	commitIDs, err := getCommits(ctx, repo, ref)
 	if err != nil {
 		return err
 	}
 
-	var commitIDs []string
+	nodes.MustLockProject(projectID, func(project models.Project) {
+		project.CommitIDs = commitIDs
+		nodes.MustStoreProject(project)
+	})
+
+	if err := updateStatus(ctx, repo, projectID); err != nil {
+		return err
+	}
 
-	err = iter.ForEach(func(c *object.Commit) error {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
+	subs.Publish(models.ProjectUpserted, projectID)
+	subs.Publish(models.WorkspaceUpserted, workspaceID)
 
-		commit := models.Commit{
-			ID:       relay.EncodeID(models.NodeTypeCommit, c.Hash.String()),
-			Hash:     models.Hash(c.Hash.String()),
-			Headline: strings.Split(c.Message, "\n")[0],
-			Message:  c.Message,
-			Author:   c.Author.Name,
-			Date:     models.DateTime(c.Author.When),
-		}
+	return nil
+}
 
-		nodes.MustStoreCommit(commit)
-		commitIDs = append(commitIDs, commit.ID)
+// errNonFastForward is the message of the error returned by go-git when a pull
+// isn't a fast-forward.
+const errNonFastForward = "non-fast-forward update"
+
+// pullDiverged integrates the commits of the upstream branch into the checked
+// out branch that has diverged from it. The upstream branch must have been
+// fetched.
+func pullDiverged(
+	ctx context.Context,
+	repo *git.Repository,
+	project models.Project,
+	upstream string,
+	strategy models.PullStrategy,
+	auth transport.AuthMethod,
+) error {
+	head, err := repo.Head()
+	if err != nil {
+		return err
+	}
 
-		return nil
-	})
+	local, err := repo.CommitObject(head.Hash())
 	if err != nil {
 		return err
 	}
 
-	remoteRefName := plumbing.NewRemoteReferenceName("origin", project.Branch)
+	remoteRefName := plumbing.NewRemoteReferenceName("origin", upstream)
+
 	remoteRef, err := repo.Reference(remoteRefName, true)
 	if err != nil {
 		return err
 	}
 
-	hash := ref.Hash()
-	remoteHash := remoteRef.Hash()
+	remote, err := repo.CommitObject(remoteRef.Hash())
+	if err != nil {
+		return err
+	}
+
+	base, err := mergeBase(ctx, repo, local.Hash, remote.Hash)
+	if err != nil {
+		return err
+	}
 
-	nodes.MustLockProject(projectID, func(project models.Project) {
-		project.CommitIDs = commitIDs
-		project.IsBehind = false
-		project.IsAhead = false
+	// go-git doesn't fast-forward when the local branch is only ahead.
+	if base != nil && base.Hash == remote.Hash {
+		return git.NoErrAlreadyUpToDate
+	}
 
-		if bytes.Compare(hash[:], remoteHash[:]) != 0 {
-			project.IsAhead = true
+	if strategy != models.PullStrategyRebase && strategy != models.PullStrategyMerge {
+		// Tell which files would conflict if the branches were merged.
+		if _, err := mergeCommitEntries(repo, base, local, remote); err != nil {
+			return err
 		}
 
-		nodes.MustStoreProject(project)
-	})
+		return ErrNotFastForward
+	}
 
-	subs.Publish(models.ProjectUpserted, projectID)
-	subs.Publish(models.WorkspaceUpserted, workspaceID)
+	isDirty, err := isDirty(repo)
+	if err != nil {
+		return err
+	}
 
-	return nil
+	if isDirty {
+		return ErrDirty
+	}
+
+	var hash plumbing.Hash
+
+	if strategy == models.PullStrategyRebase {
+		hash, err = rebaseCommits(ctx, repo, local, remote, signature(repo, local.Committer))
+	} else {
+		hash, err = mergeCommits(repo, base, local, remote, remoteRefName.Short(), signature(repo, local.Committer))
+	}
+	if err != nil {
+		return err
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+
+	if err := worktree.Reset(&git.ResetOptions{Commit: hash, Mode: git.HardReset}); err != nil {
+		return err
+	}
+
+	if !project.RecurseSubmodules {
+		return nil
+	}
+
+	submodules, err := worktree.Submodules()
+	if err != nil {
+		return err
+	}
+
+	return submodules.UpdateContext(ctx, &git.SubmoduleUpdateOptions{
+		Init:              true,
+		RecurseSubmodules: recurseSubmodules(project),
+		Auth:              auth,
+	})
 }
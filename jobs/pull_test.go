@@ -0,0 +1,66 @@
+// Copyright 2019 Stratumn
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/storage/memory"
+
+	"groundcontrol/models"
+)
+
+func TestPullDiverged_ahead(t *testing.T) {
+	repo, err := git.Init(memory.NewStorage(), nil)
+	assert.NoError(t, err)
+
+	tree, err := writeTree(repo, nil)
+	assert.NoError(t, err)
+
+	now := time.Now()
+	remote := writeTestCommit(t, repo, tree, now, "remote")
+	local := writeTestCommit(t, repo, tree, now.Add(time.Minute), "local", remote)
+
+	assert.NoError(t, repo.Storer.SetReference(plumbing.NewHashReference(
+		plumbing.NewBranchReferenceName("master"),
+		local,
+	)))
+	assert.NoError(t, repo.Storer.SetReference(plumbing.NewHashReference(
+		plumbing.NewRemoteReferenceName("origin", "master"),
+		remote,
+	)))
+
+	strategies := []models.PullStrategy{
+		models.PullStrategyFfOnly,
+		models.PullStrategyMerge,
+		models.PullStrategyRebase,
+	}
+
+	for _, strategy := range strategies {
+		t.Run(string(strategy), func(t *testing.T) {
+			err := pullDiverged(context.Background(), repo, models.Project{}, "master", strategy, nil)
+			assert.Equal(t, git.NoErrAlreadyUpToDate, err)
+
+			head, err := repo.Head()
+			assert.NoError(t, err)
+			assert.Equal(t, local, head.Hash())
+		})
+	}
+}
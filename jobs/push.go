@@ -0,0 +1,181 @@
+// Copyright 2019 Stratumn
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/config"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+
+	"groundcontrol/models"
+)
+
+// Push pushes the checked out branch of a project to the branch it tracks.
+// Non-fast-forward pushes are rejected unless force is true.
+func Push(ctx context.Context, projectID string, force bool, priority models.JobPriority) (string, error) {
+	modelCtx := models.GetModelContext(ctx)
+	nodes := modelCtx.Nodes
+	subs := modelCtx.Subs
+	workspaceID := ""
+	host := ""
+
+	err := nodes.LockProjectE(projectID, func(project models.Project) error {
+		if !project.IsCloned(ctx) {
+			return ErrNotCloned
+		}
+
+		if project.IsPushing {
+			return ErrDuplicate
+		}
+
+		workspaceID = project.WorkspaceID
+		host = remoteHost(project.Repository)
+		project.IsPushing = true
+		nodes.MustStoreProject(project)
+
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	subs.Publish(models.ProjectUpserted, projectID)
+	subs.Publish(models.WorkspaceUpserted, workspaceID)
+
+	jobID := modelCtx.Jobs.Add(
+		models.GetModelContext(ctx),
+		PushJob,
+		projectID,
+		models.JobPoolGit,
+		host,
+		priority,
+		gitRetryPolicy,
+		func(ctx context.Context, _ *models.ProgressReporter) error {
+			return doPush(ctx, projectID, workspaceID, force)
+		},
+	)
+
+	return jobID, nil
+}
+
+func doPush(ctx context.Context, projectID string, workspaceID string, force bool) error {
+	modelCtx := models.GetModelContext(ctx)
+	nodes := modelCtx.Nodes
+	subs := modelCtx.Subs
+
+	defer func() {
+		nodes.MustLockProject(projectID, func(project models.Project) {
+			project.IsPushing = false
+			nodes.MustStoreProject(project)
+		})
+
+		subs.Publish(models.ProjectUpserted, projectID)
+		subs.Publish(models.WorkspaceUpserted, workspaceID)
+	}()
+
+	project := nodes.MustLoadProject(projectID)
+	workspace := project.Workspace(ctx)
+	directory := modelCtx.GetProjectPath(workspace.Slug, project.Repository, project.Branch)
+
+	repo, err := git.PlainOpen(directory)
+	if err != nil {
+		return err
+	}
+
+	auth, err := projectAuth(ctx, project)
+	if err != nil {
+		return err
+	}
+
+	// Push the checked out branch, which can differ from the configured branch.
+	branch, err := headBranch(repo)
+	if err != nil {
+		return err
+	}
+
+	upstream := upstreamBranch(repo, branch)
+	refName := plumbing.NewBranchReferenceName(branch)
+	remoteBranchName := plumbing.NewBranchReferenceName(upstream)
+	refSpec := config.RefSpec(fmt.Sprintf("%s:%s", refName, remoteBranchName))
+	if force {
+		refSpec = "+" + refSpec
+	}
+
+	err = repo.PushContext(ctx, &git.PushOptions{
+		RemoteName: "origin",
+		RefSpecs:   []config.RefSpec{refSpec},
+		Auth:       auth,
+	})
+	if err == git.NoErrAlreadyUpToDate {
+		return nil
+	}
+	if err != nil {
+		return checkAuth(ctx, projectID, checkRejected(err, refName))
+	}
+
+	// Like Git, update the remote-tracking branch to what was pushed.
+	ref, err := repo.Reference(refName, true)
+	if err != nil {
+		return err
+	}
+
+	remoteRefName := plumbing.NewRemoteReferenceName("origin", upstream)
+	err = repo.Storer.SetReference(plumbing.NewHashReference(remoteRefName, ref.Hash()))
+	if err != nil {
+		return err
+	}
+
+	return updateStatus(ctx, repo, projectID)
+}
+
+// checkRejected converts errors about refs refused during a push to a RejectedError.
+func checkRejected(err error, refName plumbing.ReferenceName) error {
+	if err == git.ErrForceNeeded {
+		return RejectedError{
+			Refs:   []string{refName.String()},
+			Reason: "non-fast-forward, force is needed",
+		}
+	}
+
+	msg := err.Error()
+
+	// Refs not fast-forwarded locally look like "non-fast-forward update: <ref>".
+	const localPrefix = "non-fast-forward update: "
+
+	if strings.HasPrefix(msg, localPrefix) {
+		return RejectedError{
+			Refs:   []string{strings.TrimPrefix(msg, localPrefix)},
+			Reason: "non-fast-forward, force is needed",
+		}
+	}
+
+	// Errors reported by the remote look like "command error on <ref>: <reason>".
+	const remotePrefix = "command error on "
+
+	if !strings.HasPrefix(msg, remotePrefix) {
+		return err
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(msg, remotePrefix), ": ", 2)
+	if len(parts) != 2 {
+		return err
+	}
+
+	return RejectedError{Refs: []string{parts[0]}, Reason: parts[1]}
+}
@@ -0,0 +1,82 @@
+// Copyright 2019 Stratumn
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport/http"
+
+	"groundcontrol/models"
+)
+
+// gitRetryPolicy retries Git jobs that failed because of a network error.
+var gitRetryPolicy = &models.RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   5 * time.Second,
+	MaxDelay:    time.Minute,
+	IsRetryable: isNetworkError,
+}
+
+// networkErrorMessages are found in the messages of network errors that
+// aren't typed.
+var networkErrorMessages = []string{
+	"connection refused",
+	"connection reset",
+	"connection timed out",
+	"broken pipe",
+	"i/o timeout",
+	"no such host",
+	"network is unreachable",
+	"TLS handshake timeout",
+	"unexpected EOF",
+}
+
+// isNetworkError tells whether an error returned by go-git is caused by the
+// network. Authentication errors aren't.
+func isNetworkError(err error) bool {
+	if unexpected, ok := err.(*plumbing.UnexpectedError); ok {
+		err = unexpected.Err
+	}
+
+	switch e := err.(type) {
+	case net.Error:
+		return true
+	case *http.Err:
+		return e.StatusCode() >= 500
+	}
+
+	if err == ErrAuth {
+		return false
+	}
+
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return true
+	}
+
+	message := err.Error()
+
+	for _, networkMessage := range networkErrorMessages {
+		if strings.Contains(message, networkMessage) {
+			return true
+		}
+	}
+
+	return false
+}
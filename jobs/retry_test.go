@@ -0,0 +1,64 @@
+// Copyright 2019 Stratumn
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"errors"
+	"io"
+	"net"
+	nethttp "net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport/http"
+)
+
+func TestIsNetworkError(t *testing.T) {
+	httpErr := func(status int) error {
+		return &http.Err{Response: &nethttp.Response{StatusCode: status}}
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"net.Error", &net.DNSError{Err: "no such host", Name: "example.com"}, true},
+		{"net.OpError", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("refused")}, true},
+		{"HTTP 500", httpErr(500), true},
+		{"HTTP 503", httpErr(503), true},
+		{"HTTP 400", httpErr(400), false},
+		{"HTTP 429", httpErr(429), false},
+		{"auth", ErrAuth, false},
+		{"EOF", io.EOF, true},
+		{"unexpected EOF", io.ErrUnexpectedEOF, true},
+		{"wrapped net.Error", plumbing.NewUnexpectedError(&net.DNSError{Err: "timeout"}), true},
+		{"wrapped HTTP 502", plumbing.NewUnexpectedError(httpErr(502)), true},
+		{"wrapped auth", plumbing.NewUnexpectedError(ErrAuth), false},
+		{"connection refused", errors.New("dial tcp 127.0.0.1:22: connect: connection refused"), true},
+		{"connection reset", errors.New("read: connection reset by peer"), true},
+		{"timeout", errors.New("read tcp: i/o timeout"), true},
+		{"TLS", errors.New("net/http: TLS handshake timeout"), true},
+		{"unknown host", errors.New("lookup example.com: no such host"), true},
+		{"repository not found", errors.New("repository not found"), false},
+		{"other", errors.New("object not found"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isNetworkError(tt.err))
+		})
+	}
+}
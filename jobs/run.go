@@ -18,7 +18,9 @@ import (
 	"context"
 	"io"
 	"os/exec"
-	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"groundcontrol/models"
 )
@@ -52,8 +54,11 @@ func Run(ctx context.Context, taskID string, env []string, priority models.JobPr
 		models.GetModelContext(ctx),
 		RunJob,
 		workspaceID,
+		models.JobPoolRun,
+		"",
 		priority,
-		func(ctx context.Context) error {
+		nil,
+		func(ctx context.Context, _ *models.ProgressReporter) error {
 			return doRun(ctx, taskID, env, workspaceID, modelCtx.SystemID)
 		},
 	)
@@ -65,7 +70,6 @@ func doRun(ctx context.Context, taskID string, env []string, workspaceID string,
 	modelCtx := models.GetModelContext(ctx)
 	nodes := modelCtx.Nodes
 	subs := modelCtx.Subs
-	log := modelCtx.Log
 	pm := modelCtx.PM
 
 	defer func() {
@@ -80,11 +84,150 @@ func doRun(ctx context.Context, taskID string, env []string, workspaceID string,
 
 	workspace := nodes.MustLoadWorkspace(workspaceID)
 	task := nodes.MustLoadTask(taskID)
-	processGroupID := ""
+
+	var (
+		processGroupID   string
+		processGroupOnce sync.Once
+	)
+
+	// Steps running in parallel might spawn processes at the same time.
+	getProcessGroupID := func() string {
+		processGroupOnce.Do(func() {
+			processGroupID = pm.CreateGroup(ctx, taskID)
+		})
+
+		return processGroupID
+	}
+
+	dependencyIDs, err := task.AllDependencyIDs(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, dependencyID := range dependencyIDs {
+		if err := runDependency(ctx, dependencyID, env, workspace, getProcessGroupID); err != nil {
+			return err
+		}
+	}
+
+	return runSteps(ctx, task, env, workspace, getProcessGroupID)
+}
+
+// runDependency runs the steps of a task another task depends on with its own
+// variables. If the task is already running, it waits for it to finish instead.
+func runDependency(
+	ctx context.Context,
+	taskID string,
+	env []string,
+	workspace models.Workspace,
+	getProcessGroupID func() string,
+) error {
+	modelCtx := models.GetModelContext(ctx)
+	nodes := modelCtx.Nodes
+	subs := modelCtx.Subs
+
+	err := nodes.LockTaskE(taskID, func(task models.Task) error {
+		if task.IsRunning {
+			return ErrDuplicate
+		}
+
+		task.IsRunning = true
+		nodes.MustStoreTask(task)
+
+		return nil
+	})
+	if err == ErrDuplicate {
+		modelCtx.Log.InfoWithOwner(taskID, "waiting for dependency to finish because it is already running")
+		return waitTaskDone(ctx, taskID)
+	}
+	if err != nil {
+		return err
+	}
+
+	subs.Publish(models.TaskUpserted, taskID)
+
+	defer func() {
+		nodes.MustLockTask(taskID, func(task models.Task) {
+			task.IsRunning = false
+			nodes.MustStoreTask(task)
+		})
+
+		subs.Publish(models.TaskUpserted, taskID)
+	}()
+
+	task := nodes.MustLoadTask(taskID)
+
+	return runSteps(ctx, task, task.Env(ctx, env), workspace, getProcessGroupID)
+}
+
+// waitTaskDone blocks until a task isn't running.
+func waitTaskDone(ctx context.Context, taskID string) error {
+	modelCtx := models.GetModelContext(ctx)
+
+	subsCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	upsertedCh := make(chan struct{}, 1)
+
+	modelCtx.Subs.Subscribe(subsCtx, models.TaskUpserted, 0, func(msg interface{}) {
+		if msg.(string) != taskID {
+			return
+		}
+
+		select {
+		case upsertedCh <- struct{}{}:
+		default:
+		}
+	})
+
+	for modelCtx.Nodes.MustLoadTask(taskID).IsRunning {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-upsertedCh:
+		}
+	}
+
+	return nil
+}
+
+func runSteps(
+	ctx context.Context,
+	task models.Task,
+	env []string,
+	workspace models.Workspace,
+	getProcessGroupID func() string,
+) error {
+	nodes := models.GetModelContext(ctx).Nodes
 
 	for _, stepID := range task.StepIDs {
 		step := nodes.MustLoadStep(stepID)
 
+		if step.Parallel {
+			err := runParallel(ctx, step.ProjectIDs, step.MaxParallel, func(ctx context.Context, projectID string) error {
+				for _, commandID := range step.CommandIDs {
+					select {
+					case <-ctx.Done():
+						return ctx.Err()
+					default:
+					}
+
+					command := nodes.MustLoadCommand(commandID)
+
+					if err := runCommand(ctx, workspace, projectID, command, env, getProcessGroupID); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
 		for _, commandID := range step.CommandIDs {
 			command := nodes.MustLoadCommand(commandID)
 
@@ -95,41 +238,166 @@ func doRun(ctx context.Context, taskID string, env []string, workspaceID string,
 				default:
 				}
 
-				project := nodes.MustLoadProject(projectID)
+				if err := runCommand(ctx, workspace, projectID, command, env, getProcessGroupID); err != nil {
+					return err
+				}
+			}
+		}
+	}
 
-				log.InfoWithOwner(project.ID, command.Command)
+	return nil
+}
 
-				projectPath := modelCtx.GetProjectPath(workspace.Slug, project.Repository, project.Branch)
-				parts := strings.Split(command.Command, " ")
+// runParallel calls the function for every project concurrently, but with at most maxParallel
+// calls running at the same time (no limit if zero).
+// The context given to the function is canceled as soon as one of the calls fails,
+// and the first error is returned.
+func runParallel(
+	ctx context.Context,
+	projectIDs []string,
+	maxParallel int,
+	fn func(context.Context, string) error,
+) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-				if len(parts) > 0 && parts[0] == "spawn" {
-					if processGroupID == "" {
-						processGroupID = pm.CreateGroup(ctx, taskID)
-					}
+	if maxParallel < 1 || maxParallel > len(projectIDs) {
+		maxParallel = len(projectIDs)
+	}
 
-					rest := strings.Join(parts[1:], " ")
-					pm.Run(ctx, rest, env, processGroupID, project.ID)
+	semaphore := make(chan struct{}, maxParallel)
+	errCh := make(chan error, len(projectIDs))
+	waitGroup := sync.WaitGroup{}
 
-					continue
-				}
+loop:
+	for _, projectID := range projectIDs {
+		select {
+		case <-ctx.Done():
+			break loop
+		case semaphore <- struct{}{}:
+		}
 
-				stdout := models.CreateLineWriter(log.InfoWithOwner, project.ID)
-				stderr := models.CreateLineWriter(log.WarningWithOwner, project.ID)
-				err := run(ctx, command.Command, projectPath, env, stdout, stderr)
+		waitGroup.Add(1)
 
-				stdout.Close()
-				stderr.Close()
+		go func(projectID string) {
+			defer func() {
+				<-semaphore
+				waitGroup.Done()
+			}()
 
-				if err != nil {
-					return err
-				}
+			if err := fn(ctx, projectID); err != nil {
+				errCh <- err
+				cancel()
 			}
+		}(projectID)
+	}
+
+	waitGroup.Wait()
+	close(errCh)
+
+	if err, ok := <-errCh; ok {
+		return err
+	}
+
+	return ctx.Err()
+}
+
+func runCommand(
+	ctx context.Context,
+	workspace models.Workspace,
+	projectID string,
+	command models.Command,
+	env []string,
+	getProcessGroupID func() string,
+) error {
+	modelCtx := models.GetModelContext(ctx)
+	log := modelCtx.Log
+	project := modelCtx.Nodes.MustLoadProject(projectID)
+
+	log.InfoWithOwner(project.ID, "%s", command.Command)
+
+	projectPath := modelCtx.GetProjectPath(workspace.Slug, project.Repository, project.Branch)
+
+	if command.Background {
+		processID := modelCtx.PM.Run(ctx, command, env, getProcessGroupID(), project.ID)
+
+		if command.WaitReady {
+			log.InfoWithOwner(project.ID, "waiting for process to be ready")
+			return modelCtx.PM.WaitReady(ctx, processID)
 		}
+
+		return nil
 	}
 
-	return nil
+	var err error
+
+	for attempt := 0; attempt <= command.Retries; attempt++ {
+		if attempt > 0 {
+			log.WarningWithOwner(
+				project.ID,
+				"retrying command in %s (%d/%d)",
+				time.Duration(command.RetryDelay),
+				attempt,
+				command.Retries,
+			)
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Duration(command.RetryDelay)):
+			}
+		}
+
+		err = runAttempt(ctx, command, projectPath, env, project.ID)
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+
+		log.WarningWithOwner(project.ID, "command failed because %s", err.Error())
+	}
+
+	if command.ContinueOnError {
+		log.WarningWithOwner(project.ID, "ignoring command failure")
+		return nil
+	}
+
+	return err
+}
+
+// runAttempt runs a command once, enforcing the command timeout if there is one.
+func runAttempt(
+	ctx context.Context,
+	command models.Command,
+	dir string,
+	env []string,
+	projectID string,
+) error {
+	log := models.GetModelContext(ctx).Log
+
+	if command.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(command.Timeout))
+		defer cancel()
+	}
+
+	stdout := models.CreateLineWriter(log.InfoWithOwner, projectID)
+	stderr := models.CreateLineWriter(log.WarningWithOwner, projectID)
+	env = append(append([]string(nil), env...), command.Env...)
+	err := run(ctx, command.Command, dir, env, stdout, stderr)
+
+	stdout.Close()
+	stderr.Close()
+
+	if err == context.DeadlineExceeded {
+		return ErrTimeout
+	}
+
+	return err
 }
 
+// run runs a command and blocks until it exits.
+// The whole process group is killed when the context is done,
+// otherwise child processes could outlive the command.
 func run(
 	ctx context.Context,
 	command string,
@@ -138,11 +406,32 @@ func run(
 	stdout io.Writer,
 	stderr io.Writer,
 ) error {
-	cmd := exec.CommandContext(ctx, "bash", "-l", "-c", command)
+	cmd := exec.Command("bash", "-l", "-c", command)
 	cmd.Dir = dir
 	cmd.Env = env
 	cmd.Stdout = stdout
 	cmd.Stderr = stderr
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		case <-done:
+		}
+	}()
+
+	err := cmd.Wait()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
 
-	return cmd.Run()
+	return err
 }
@@ -0,0 +1,63 @@
+// Copyright 2019 Stratumn
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"groundcontrol/models"
+	"groundcontrol/pubsub"
+	"groundcontrol/relay"
+)
+
+func TestWaitTaskDone(t *testing.T) {
+	modelCtx := &models.ModelContext{
+		Nodes: &models.NodeManager{},
+		Subs:  pubsub.New(10),
+	}
+	ctx := models.WithModelContext(context.Background(), modelCtx)
+
+	taskID := relay.EncodeID(models.NodeTypeTask, "0")
+	modelCtx.Nodes.MustStoreTask(models.Task{ID: taskID, IsRunning: true})
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- waitTaskDone(ctx, taskID)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("waitTaskDone returned while the task is running")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	modelCtx.Nodes.MustLockTask(taskID, func(task models.Task) {
+		task.IsRunning = false
+		modelCtx.Nodes.MustStoreTask(task)
+	})
+	modelCtx.Subs.Publish(models.TaskUpserted, taskID)
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("waitTaskDone didn't return after the task finished")
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright 2019 Stratumn
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"context"
+	"time"
+
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+
+	"groundcontrol/models"
+)
+
+// SnapshotWorkspace records the head of every cloned project of a workspace
+// and saves it to the snapshot lockfile of the workspace.
+func SnapshotWorkspace(ctx context.Context, workspaceID string) (models.WorkspaceSnapshot, error) {
+	modelCtx := models.GetModelContext(ctx)
+	nodes := modelCtx.Nodes
+
+	workspace, err := nodes.LoadWorkspace(workspaceID)
+	if err != nil {
+		return models.WorkspaceSnapshot{}, err
+	}
+
+	snapshot := models.WorkspaceSnapshot{
+		Filename:  workspace.SnapshotFilename(),
+		Workspace: workspace.Slug,
+		Date:      models.DateTime(time.Now()),
+		Projects:  []models.ProjectSnapshot{},
+	}
+
+	for _, projectID := range workspace.ProjectIDs {
+		project := nodes.MustLoadProject(projectID)
+
+		if !project.IsCloned(ctx) {
+			continue
+		}
+
+		repo, err := openProject(ctx, project)
+		if err != nil {
+			return models.WorkspaceSnapshot{}, err
+		}
+
+		head, err := repo.Head()
+		if err != nil {
+			return models.WorkspaceSnapshot{}, err
+		}
+
+		isDirty, err := isDirty(repo)
+		if err != nil {
+			return models.WorkspaceSnapshot{}, err
+		}
+
+		projectSnapshot := models.ProjectSnapshot{
+			Slug:       project.Slug,
+			Repository: project.Repository,
+			Hash:       models.Hash(head.Hash().String()),
+			IsDirty:    isDirty,
+		}
+
+		if head.Name().IsBranch() {
+			branch := head.Name().Short()
+			projectSnapshot.Branch = &branch
+		}
+
+		snapshot.Projects = append(snapshot.Projects, projectSnapshot)
+	}
+
+	return snapshot, snapshot.Save()
+}
+
+// RestoreSnapshot checks out every project of a workspace at the hash recorded
+// in the snapshot lockfile of the workspace. It doesn't add any job if one of
+// the projects has uncommitted changes.
+func RestoreSnapshot(ctx context.Context, workspaceID string, priority models.JobPriority) ([]string, error) {
+	modelCtx := models.GetModelContext(ctx)
+	nodes := modelCtx.Nodes
+
+	workspace, err := nodes.LoadWorkspace(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot, err := models.LoadWorkspaceSnapshotYAML(workspace.SnapshotFilename())
+	if err != nil {
+		return nil, err
+	}
+
+	projectSnapshots := map[string]models.ProjectSnapshot{}
+	for _, projectSnapshot := range snapshot.Projects {
+		projectSnapshots[projectSnapshot.Slug] = projectSnapshot
+	}
+
+	var projectIDs []string
+
+	for _, projectID := range workspace.ProjectIDs {
+		project := nodes.MustLoadProject(projectID)
+
+		if _, ok := projectSnapshots[project.Slug]; !ok || !project.IsCloned(ctx) {
+			continue
+		}
+
+		repo, err := openProject(ctx, project)
+		if err != nil {
+			return nil, err
+		}
+
+		isDirty, err := isDirty(repo)
+		if err != nil {
+			return nil, err
+		}
+
+		if isDirty {
+			modelCtx.Log.ErrorWithOwner(projectID, "can't restore snapshot because the project has uncommitted changes")
+			return nil, ErrDirty
+		}
+
+		projectIDs = append(projectIDs, projectID)
+	}
+
+	var jobIDs []string
+
+	for _, projectID := range projectIDs {
+		projectSnapshot := projectSnapshots[nodes.MustLoadProject(projectID).Slug]
+
+		jobID, err := addCheckoutJob(
+			ctx,
+			projectID,
+			RestoreSnapshotJob,
+			priority,
+			func(ctx context.Context, repo *git.Repository) error {
+				return doRestoreSnapshot(ctx, repo, projectID, projectSnapshot)
+			},
+		)
+		if err != nil {
+			return jobIDs, err
+		}
+
+		jobIDs = append(jobIDs, jobID)
+	}
+
+	return jobIDs, nil
+}
+
+func doRestoreSnapshot(
+	ctx context.Context,
+	repo *git.Repository,
+	projectID string,
+	snapshot models.ProjectSnapshot,
+) error {
+	hash := plumbing.NewHash(string(snapshot.Hash))
+
+	// The commit could have been created after the last fetch.
+	if _, err := repo.CommitObject(hash); err != nil {
+		auth, err := projectAuth(ctx, models.GetModelContext(ctx).Nodes.MustLoadProject(projectID))
+		if err != nil {
+			return err
+		}
+
+		err = repo.FetchContext(ctx, &git.FetchOptions{Auth: auth})
+		if err != nil && err != git.NoErrAlreadyUpToDate {
+			return checkAuth(ctx, projectID, err)
+		}
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+
+	// Check out the branch if it still points to the hash, otherwise detach the head.
+	if snapshot.Branch != nil {
+		refName := plumbing.NewBranchReferenceName(*snapshot.Branch)
+		if ref, err := repo.Reference(refName, true); err == nil && ref.Hash() == hash {
+			return worktree.Checkout(&git.CheckoutOptions{Branch: refName})
+		}
+	}
+
+	return worktree.Checkout(&git.CheckoutOptions{Hash: hash})
+}
+
+// openProject opens the Git repository of a cloned project.
+func openProject(ctx context.Context, project models.Project) (*git.Repository, error) {
+	modelCtx := models.GetModelContext(ctx)
+	workspace := project.Workspace(ctx)
+	directory := modelCtx.GetProjectPath(workspace.Slug, project.Repository, project.Branch)
+
+	return git.PlainOpen(directory)
+}
+
+// isDirty returns whether the worktree of a repository has uncommitted changes.
+func isDirty(repo *git.Repository) (bool, error) {
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return false, err
+	}
+
+	status, err := worktree.Status()
+	if err != nil {
+		return false, err
+	}
+
+	return !status.IsClean(), nil
+}
@@ -0,0 +1,45 @@
+// Copyright 2019 Stratumn
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+// Command represents a step command in the app.
+type Command struct {
+	ID              string   `json:"id"`
+	Command         string   `json:"command"`
+	Retries         int      `json:"retries"`
+	RetryDelay      Duration `json:"retryDelay"`
+	Timeout         Duration `json:"timeout"`
+	ContinueOnError bool     `json:"continueOnError"`
+	// Env contains additional environment variables.
+	// Each entry is of the form "key=value".
+	Env         []string `json:"env"`
+	Background  bool     `json:"background"`
+	Name        string   `json:"name"`
+	StopSignal  string   `json:"stopSignal"`
+	StopTimeout Duration `json:"stopTimeout"`
+	KillTimeout Duration `json:"killTimeout"`
+	// RestartPolicy tells when to restart a background process after it exits.
+	RestartPolicy RestartPolicy `json:"restartPolicy"`
+	MaxRestarts   int           `json:"maxRestarts"`
+	RestartDelay  Duration      `json:"restartDelay"`
+	// ReadinessProbe tells when a background process is ready.
+	ReadinessProbe *ReadinessProbe `json:"readinessProbe"`
+	WaitReady      bool            `json:"waitReady"`
+	// TTY tells whether to start a background process in a pseudo-terminal.
+	TTY bool `json:"tty"`
+}
+
+// IsNode tells gqlgen that it implements Node.
+func (Command) IsNode() {}
@@ -0,0 +1,120 @@
+// Copyright 2019 Stratumn
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport/http"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport/ssh"
+)
+
+// CredentialConfig contains all the data in a YAML Git credential config file.
+// It applies to repositories on the given host. Only one of SSHKey, SSHAgent
+// and Token must be set. SSHKey is the path to a private key file, and
+// Passphrase and Token are names of keys in the keys config.
+type CredentialConfig struct {
+	Host       string `json:"host"`
+	Username   string `json:"username"`
+	SSHKey     string `json:"sshKey" yaml:"ssh-key"`
+	Passphrase string `json:"passphrase"`
+	SSHAgent   bool   `json:"sshAgent" yaml:"ssh-agent"`
+	Token      string `json:"token"`
+}
+
+// ResolveGitAuth returns the authentication method for a repository using the
+// first credential matching its host, or nil if there are none.
+// Credentials are searched in order, so more specific ones should come first.
+func ResolveGitAuth(
+	keys *KeysConfig,
+	repository string,
+	credentials ...[]CredentialConfig,
+) (transport.AuthMethod, error) {
+	endpoint, err := transport.NewEndpoint(repository)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, list := range credentials {
+		for _, credential := range list {
+			if strings.EqualFold(credential.Host, endpoint.Host) {
+				return credential.authMethod(keys, endpoint)
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// authMethod creates the authentication method for the endpoint.
+func (c CredentialConfig) authMethod(
+	keys *KeysConfig,
+	endpoint *transport.Endpoint,
+) (transport.AuthMethod, error) {
+	set := 0
+	for _, ok := range []bool{c.SSHKey != "", c.SSHAgent, c.Token != ""} {
+		if ok {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, ErrCredential
+	}
+
+	username := c.Username
+	if username == "" {
+		username = endpoint.User
+	}
+	if username == "" {
+		username = "git"
+	}
+
+	switch {
+	case c.SSHKey != "":
+		passphrase := ""
+		if c.Passphrase != "" {
+			var err error
+			if passphrase, err = keys.lookup(c.Passphrase); err != nil {
+				return nil, err
+			}
+		}
+
+		path, err := homedir.Expand(c.SSHKey)
+		if err != nil {
+			return nil, err
+		}
+
+		return ssh.NewPublicKeysFromFile(username, filepath.Clean(path), passphrase)
+
+	case c.SSHAgent:
+		if os.Getenv("SSH_AUTH_SOCK") == "" {
+			return nil, ErrSSHAgent
+		}
+
+		return ssh.NewSSHAgentAuth(username)
+
+	default:
+		token, err := keys.lookup(c.Token)
+		if err != nil {
+			return nil, err
+		}
+
+		return &http.BasicAuth{Username: username, Password: token}, nil
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2019 Stratumn
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport/http"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestResolveGitAuth(t *testing.T) {
+	keys := &KeysConfig{Keys: map[string]string{"GITHUB_TOKEN": "secret"}}
+
+	workspaceCredentials := []CredentialConfig{{
+		Host:  "github.com",
+		Token: "GITHUB_TOKEN",
+	}}
+
+	sourcesCredentials := []CredentialConfig{{
+		Host:     "github.com",
+		SSHAgent: true,
+	}, {
+		Host:  "gitlab.com",
+		Token: "GITLAB_TOKEN",
+	}, {
+		Host:     "bitbucket.org",
+		Token:    "GITHUB_TOKEN",
+		SSHAgent: true,
+	}}
+
+	auth, err := ResolveGitAuth(keys, "https://github.com/stratumn/groundcontrol.git", workspaceCredentials, sourcesCredentials)
+	assert.NoError(t, err)
+	assert.Equal(t, &http.BasicAuth{Username: "git", Password: "secret"}, auth)
+
+	auth, err = ResolveGitAuth(keys, "https://example.com/repo.git", workspaceCredentials, sourcesCredentials)
+	assert.NoError(t, err)
+	assert.Nil(t, auth)
+
+	_, err = ResolveGitAuth(keys, "https://gitlab.com/repo.git", workspaceCredentials, sourcesCredentials)
+	assert.Equal(t, ErrKeyNotFound, err)
+
+	_, err = ResolveGitAuth(keys, "git@bitbucket.org:repo.git", workspaceCredentials, sourcesCredentials)
+	assert.Equal(t, ErrCredential, err)
+}
+
+func TestCredentialConfig_yaml(t *testing.T) {
+	var credentials []CredentialConfig
+
+	err := yaml.UnmarshalStrict([]byte(`
+- host: github.com
+  ssh-key: ~/.ssh/id_rsa
+  passphrase: SSH_PASSPHRASE
+- host: gitlab.com
+  ssh-agent: true
+`), &credentials)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []CredentialConfig{{
+		Host:       "github.com",
+		SSHKey:     "~/.ssh/id_rsa",
+		Passphrase: "SSH_PASSPHRASE",
+	}, {
+		Host:     "gitlab.com",
+		SSHAgent: true,
+	}}, credentials)
+}
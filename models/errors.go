@@ -16,6 +16,8 @@ package models
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 )
 
 // Errors.
@@ -26,4 +28,53 @@ var (
 	ErrLastNegative  = errors.New("last cannot be negative")
 	ErrNotRunning    = errors.New("project isn't running")
 	ErrNotStopped    = errors.New("project isn't stopped")
+	ErrNotQueued     = errors.New("job isn't queued")
+	ErrCycle         = errors.New("dependencies form a cycle")
+	ErrSignal        = errors.New("unsupported signal")
+	ErrRestartPolicy = errors.New("unsupported restart policy")
+	ErrProbe         = errors.New("readiness probe needs exactly one of tcp, http or log")
+	ErrNotReady      = errors.New("process exited before being ready")
+	ErrNoTerminal    = errors.New("process doesn't have a terminal")
+	ErrCredential    = errors.New("credential needs exactly one of ssh-key, ssh-agent or token")
+	ErrSSHAgent      = errors.New("SSH agent isn't running")
+	ErrKeyNotFound   = errors.New("key not found")
+	ErrSubmodules    = errors.New("submodules must be empty or recursive")
+	ErrDepthNegative = errors.New("depth cannot be negative")
+	ErrSparsePaths   = errors.New("sparse checkouts aren't supported, remove sparsePaths from the project")
+	ErrJobPool       = errors.New("unsupported job pool")
+	ErrConcurrency   = errors.New("concurrency must be positive")
+	ErrHostNegative  = errors.New("host concurrency cannot be negative")
+	ErrPullStrategy  = errors.New("unsupported pull strategy")
 )
+
+// DependencyError is returned when a task depends on a task that doesn't exist.
+type DependencyError struct {
+	Task       string
+	Dependency string
+}
+
+// Error implements the error interface.
+func (e DependencyError) Error() string {
+	return fmt.Sprintf("task %q depends on %q: %s", e.Task, e.Dependency, ErrNotFound)
+}
+
+// Unwrap returns ErrNotFound.
+func (e DependencyError) Unwrap() error {
+	return ErrNotFound
+}
+
+// CycleError is returned when dependencies form a cycle. The path starts and
+// ends with the same dependency.
+type CycleError struct {
+	Path []string
+}
+
+// Error implements the error interface.
+func (e CycleError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrCycle, strings.Join(e.Path, " -> "))
+}
+
+// Unwrap returns ErrCycle.
+func (e CycleError) Unwrap() error {
+	return ErrCycle
+}
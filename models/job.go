@@ -14,7 +14,10 @@
 
 package models
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // Job represents a job in the app.
 type Job struct {
@@ -25,11 +28,52 @@ type Job struct {
 	Status    JobStatus   `json:"status"`
 	Priority  JobPriority `json:"priority"`
 	OwnerID   string      `json:"ownerId"`
+
+	// Pool is the pool whose concurrency limits apply to the job.
+	Pool JobPool `json:"pool"`
+	// Host is the remote host the job connects to.
+	Host *string `json:"host"`
+
+	Progress        float64 `json:"progress"`
+	ProgressMessage *string `json:"progressMessage"`
+
+	// Attempt is the number of times the job started running.
+	Attempt     int       `json:"attempt"`
+	NextRetryAt *DateTime `json:"nextRetryAt"`
+
+	// Error is the error of the last failed attempt.
+	Error *string `json:"error"`
+	// StartedAt is when the first attempt started.
+	StartedAt  *DateTime `json:"startedAt"`
+	FinishedAt *DateTime `json:"finishedAt"`
 }
 
 // IsNode tells gqlgen that it implements Node.
 func (Job) IsNode() {}
 
+// QueuePosition returns the position of the job in the queue, or nil if it
+// isn't queued.
+func (j Job) QueuePosition(ctx context.Context) *int {
+	return GetModelContext(ctx).Jobs.QueuePosition(j.ID)
+}
+
+// Duration returns how long the job has been running since its first attempt
+// started, or nil if it hasn't started.
+func (j Job) Duration() *Duration {
+	if j.StartedAt == nil {
+		return nil
+	}
+
+	end := time.Now()
+	if j.FinishedAt != nil {
+		end = time.Time(*j.FinishedAt)
+	}
+
+	duration := Duration(end.Sub(time.Time(*j.StartedAt)))
+
+	return &duration
+}
+
 // Owner returns the node associated with the job.
 func (j Job) Owner(ctx context.Context) Node {
 	return GetModelContext(ctx).Nodes.MustLoad(j.OwnerID)
@@ -0,0 +1,37 @@
+// Copyright 2019 Stratumn
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJob_Duration(t *testing.T) {
+	job := Job{}
+	assert.Nil(t, job.Duration())
+
+	startedAt := DateTime(time.Now().Add(-time.Minute))
+	finishedAt := DateTime(time.Time(startedAt).Add(30 * time.Second))
+	job.StartedAt = &startedAt
+	job.FinishedAt = &finishedAt
+
+	assert.Equal(t, Duration(30*time.Second), *job.Duration())
+
+	job.FinishedAt = nil
+	assert.True(t, time.Duration(*job.Duration()) >= time.Minute)
+}
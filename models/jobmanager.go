@@ -26,23 +26,61 @@ import (
 )
 
 // JobManager manages creating and running jobs.
+//
+// Jobs belong to a pool which limits how many of them can run concurrently,
+// overall and for each remote host they connect to.
+//
+// Finished jobs are deleted when there are more than the history cap or when
+// they are older than the history max age. Failed jobs can be kept longer.
 type JobManager struct {
 	queue   *queue.Queue
 	cancels sync.Map
+	// retries are the timers of the jobs waiting to be retried.
+	retries sync.Map
+
+	concurrencyMu sync.Mutex
+	concurrency   map[JobPool]JobConcurrency
+	hosts         map[JobPool]map[string]struct{}
+
+	historyCap          int
+	historyMaxAge       time.Duration
+	failedHistoryMaxAge time.Duration
 
 	lastID uint64
 
-	queuedCounter  int64
-	runningCounter int64
-	doneCounter    int64
-	failedCounter  int64
+	queuedCounter   int64
+	runningCounter  int64
+	doneCounter     int64
+	failedCounter   int64
+	canceledCounter int64
+	retryingCounter int64
 }
 
-// NewJobManager creates a JobManager with given concurrency.
-func NewJobManager(concurrency int) *JobManager {
-	return &JobManager{
-		queue: queue.New(concurrency),
+// NewJobManager creates a JobManager with given concurrency and history
+// limits. Pools without concurrency limits can run any number of jobs.
+// A history limit of zero is disabled. If the failed history max age isn't
+// zero, failed jobs are kept that long regardless of the other limits.
+func NewJobManager(
+	concurrency []JobConcurrency,
+	historyCap int,
+	historyMaxAge time.Duration,
+	failedHistoryMaxAge time.Duration,
+) *JobManager {
+	j := &JobManager{
+		queue:               queue.New(),
+		concurrency:         map[JobPool]JobConcurrency{},
+		hosts:               map[JobPool]map[string]struct{}{},
+		historyCap:          historyCap,
+		historyMaxAge:       historyMaxAge,
+		failedHistoryMaxAge: failedHistoryMaxAge,
+	}
+
+	for _, c := range concurrency {
+		j.concurrency[c.Pool] = c
+		j.queue.SetLimit(string(c.Pool), c.Concurrency)
 	}
+
+	return j
 }
 
 // Work starts running jobs and blocks until the context is done.
@@ -51,18 +89,29 @@ func (j *JobManager) Work(ctx context.Context) error {
 }
 
 // Add adds a job to the queue and returns the job's ID.
+//
+// The job runs when its pool allows it. If the host isn't empty, the job also
+// counts towards the host concurrency of the pool.
+//
+// The job function receives a ProgressReporter it can use to report its
+// progress. If the retry policy isn't nil, the job is queued again after a
+// delay when it fails with an error the policy considers retryable.
 func (j *JobManager) Add(
 	modelCtx *ModelContext,
 	name string,
 	ownerID string,
+	pool JobPool,
+	host string,
 	priority JobPriority,
-	fn func(ctx context.Context) error,
+	retry *RetryPolicy,
+	fn func(ctx context.Context, progress *ProgressReporter) error,
 ) string {
 	id := atomic.AddUint64(&j.lastID, 1)
 	now := DateTime(time.Now())
 	job := Job{
 		ID:        relay.EncodeID(NodeTypeJob, fmt.Sprint(id)),
 		Priority:  priority,
+		Pool:      pool,
 		Name:      name,
 		Status:    JobStatusQueued,
 		CreatedAt: now,
@@ -70,6 +119,10 @@ func (j *JobManager) Add(
 		OwnerID:   ownerID,
 	}
 
+	if host != "" {
+		job.Host = &host
+	}
+
 	modelCtx.Log.DebugWithOwner(job.ID, "job queued")
 	modelCtx.Nodes.MustStoreJob(job)
 	modelCtx.Subs.Publish(JobUpserted, job.ID)
@@ -80,14 +133,18 @@ func (j *JobManager) Add(
 	})
 
 	atomic.AddInt64(&j.queuedCounter, 1)
+	j.prune(modelCtx)
 	j.publishMetrics(modelCtx)
 
-	do := j.queue.Do
-	if priority == JobPriorityHigh {
-		do = j.queue.DoHi
-	}
+	hi := priority == JobPriorityHigh
+	pools := j.pools(pool, host)
+
+	var run func()
+
+	run = func() {
+		// The jobs that were behind this one moved up.
+		j.publishQueue(modelCtx, 0)
 
-	go do(func() {
 		modelCtx.Log.DebugWithOwner(job.ID, "job running")
 
 		ctx, cancel := context.WithCancel(WithModelContext(context.Background(), modelCtx))
@@ -96,38 +153,131 @@ func (j *JobManager) Add(
 		j.cancels.Store(job.ID, cancel)
 		defer j.cancels.Delete(job.ID)
 
+		now := DateTime(time.Now())
+
+		if job.StartedAt == nil {
+			job.StartedAt = &now
+		}
+
 		job.Status = JobStatusRunning
-		job.UpdatedAt = DateTime(time.Now())
+		job.Attempt++
+		job.NextRetryAt = nil
+		job.Progress = 0
+		job.ProgressMessage = nil
+		job.UpdatedAt = now
 		modelCtx.Nodes.MustStoreJob(job)
 		modelCtx.Subs.Publish(JobUpserted, job.ID)
 		atomic.AddInt64(&j.runningCounter, 1)
 		atomic.AddInt64(&j.queuedCounter, -1)
 		j.publishMetrics(modelCtx)
 
-		if err := fn(ctx); err != nil {
+		progress := newProgressReporter(modelCtx, job.ID)
+		err := fn(ctx, progress)
+		progress.close()
+
+		job.Progress, job.ProgressMessage = progress.values()
+
+		if err != nil {
+			message := err.Error()
+			job.Error = &message
+		}
+
+		// Don't retry jobs that were stopped.
+		if err != nil && ctx.Err() == nil && retry.shouldRetry(job.Attempt, err) {
+			delay := retry.delay(job.Attempt)
+			modelCtx.Log.WarningWithOwner(
+				job.ID,
+				"job failed because %s, retrying in %s",
+				err.Error(),
+				delay,
+			)
+
+			nextRetryAt := DateTime(time.Now().Add(delay))
+			job.Status = JobStatusRetrying
+			job.NextRetryAt = &nextRetryAt
+			job.UpdatedAt = DateTime(time.Now())
+			modelCtx.Nodes.MustStoreJob(job)
+
+			modelCtx.Subs.Publish(JobUpserted, job.ID)
+			atomic.AddInt64(&j.runningCounter, -1)
+			atomic.AddInt64(&j.retryingCounter, 1)
+			j.publishMetrics(modelCtx)
+
+			// Stop can't cancel the timer until it is stored.
+			modelCtx.Nodes.MustLockJob(job.ID, func(Job) {
+				timer := time.AfterFunc(delay, func() {
+					j.requeue(modelCtx, job.ID, hi, pools, run)
+				})
+				j.retries.Store(job.ID, timer)
+			})
+
+			return
+		}
+
+		if err != nil {
 			modelCtx.Log.ErrorWithOwner(job.ID, "job failed because %s", err.Error())
 			job.Status = JobStatusFailed
 			atomic.AddInt64(&j.failedCounter, 1)
 		} else {
 			modelCtx.Log.DebugWithOwner(job.ID, "job done")
 			job.Status = JobStatusDone
+			job.Progress = 1
+			job.Error = nil
 			atomic.AddInt64(&j.doneCounter, 1)
 		}
 
-		job.UpdatedAt = DateTime(time.Now())
+		now = DateTime(time.Now())
+		job.UpdatedAt = now
+		job.FinishedAt = &now
 		modelCtx.Nodes.MustStoreJob(job)
 
 		modelCtx.Subs.Publish(JobUpserted, job.ID)
 		atomic.AddInt64(&j.runningCounter, -1)
+		j.prune(modelCtx)
 		j.publishMetrics(modelCtx)
-	})
+	}
+
+	j.queue.Push(job.ID, hi, run, pools...)
+
+	if position, err := j.queue.Position(job.ID); err == nil {
+		j.publishQueue(modelCtx, position)
+	}
 
 	return job.ID
 }
 
-// Stop cancels a running job.
+// Stop cancels a running job or removes a queued job from the queue.
 func (j *JobManager) Stop(modelCtx *ModelContext, id string) error {
 	return modelCtx.Nodes.LockJobE(id, func(job Job) error {
+		if job.Status == JobStatusQueued {
+			return j.cancel(modelCtx, job)
+		}
+
+		if job.Status == JobStatusRetrying {
+			if actual, ok := j.retries.Load(id); ok {
+				actual.(*time.Timer).Stop()
+				j.retries.Delete(id)
+			}
+
+			// The job won't be queued again once its status changed, in case
+			// the timer already fired.
+			modelCtx.Log.DebugWithOwner(job.ID, "job canceled")
+
+			now := DateTime(time.Now())
+			job.Status = JobStatusCanceled
+			job.NextRetryAt = nil
+			job.UpdatedAt = now
+			job.FinishedAt = &now
+			modelCtx.Nodes.MustStoreJob(job)
+			modelCtx.Subs.Publish(JobUpserted, id)
+
+			atomic.AddInt64(&j.retryingCounter, -1)
+			atomic.AddInt64(&j.canceledCounter, 1)
+			j.publishMetrics(modelCtx)
+
+			return nil
+		}
+
 		if job.Status != JobStatusRunning {
 			return ErrNotRunning
 		}
@@ -149,6 +299,280 @@ func (j *JobManager) Stop(modelCtx *ModelContext, id string) error {
 	})
 }
 
+// Move moves a queued job to the given position in the queue.
+// Position zero is the next job to run.
+func (j *JobManager) Move(modelCtx *ModelContext, id string, position int) error {
+	return modelCtx.Nodes.LockJobE(id, func(job Job) error {
+		if job.Status != JobStatusQueued {
+			return ErrNotQueued
+		}
+
+		oldPosition, err := j.queue.Position(id)
+		if err != nil {
+			return ErrNotQueued
+		}
+
+		if err := j.queue.Move(id, position); err != nil {
+			return ErrNotQueued
+		}
+
+		if position < oldPosition {
+			oldPosition = position
+		}
+
+		if oldPosition < 0 {
+			oldPosition = 0
+		}
+
+		j.publishQueue(modelCtx, oldPosition)
+
+		return nil
+	})
+}
+
+// QueuePosition returns the position of a job in the queue, or nil if the job
+// isn't queued.
+func (j *JobManager) QueuePosition(id string) *int {
+	position, err := j.queue.Position(id)
+	if err != nil {
+		return nil
+	}
+
+	return &position
+}
+
+// Concurrency returns the concurrency limits of the job pools.
+func (j *JobManager) Concurrency() []JobConcurrency {
+	j.concurrencyMu.Lock()
+	defer j.concurrencyMu.Unlock()
+
+	var concurrency []JobConcurrency
+
+	for _, pool := range AllJobPool {
+		if c, ok := j.concurrency[pool]; ok {
+			concurrency = append(concurrency, c)
+		}
+	}
+
+	return concurrency
+}
+
+// SetConcurrency changes the concurrency limits of a pool. Limits that are nil
+// are kept. Queued jobs start right away if the limits were raised.
+func (j *JobManager) SetConcurrency(
+	pool JobPool,
+	concurrency *int,
+	hostConcurrency *int,
+) (JobConcurrency, error) {
+	if !pool.IsValid() {
+		return JobConcurrency{}, ErrJobPool
+	}
+
+	if concurrency != nil && *concurrency < 1 {
+		return JobConcurrency{}, ErrConcurrency
+	}
+
+	if hostConcurrency != nil && *hostConcurrency < 0 {
+		return JobConcurrency{}, ErrHostNegative
+	}
+
+	j.concurrencyMu.Lock()
+	defer j.concurrencyMu.Unlock()
+
+	c := j.concurrency[pool]
+	c.Pool = pool
+
+	if concurrency != nil {
+		c.Concurrency = *concurrency
+		j.queue.SetLimit(string(pool), c.Concurrency)
+	}
+
+	if hostConcurrency != nil {
+		c.HostConcurrency = *hostConcurrency
+
+		for host := range j.hosts[pool] {
+			j.queue.SetLimit(hostPool(pool, host), c.HostConcurrency)
+		}
+	}
+
+	j.concurrency[pool] = c
+
+	return c, nil
+}
+
+// pools returns the names of the queue pools of a job.
+func (j *JobManager) pools(pool JobPool, host string) []string {
+	if host == "" {
+		return []string{string(pool)}
+	}
+
+	j.concurrencyMu.Lock()
+	defer j.concurrencyMu.Unlock()
+
+	hosts, ok := j.hosts[pool]
+	if !ok {
+		hosts = map[string]struct{}{}
+		j.hosts[pool] = hosts
+	}
+
+	name := hostPool(pool, host)
+
+	if _, ok := hosts[host]; !ok {
+		hosts[host] = struct{}{}
+		j.queue.SetLimit(name, j.concurrency[pool].HostConcurrency)
+	}
+
+	return []string{string(pool), name}
+}
+
+// hostPool returns the name of the queue pool limiting the jobs of a pool
+// connecting to a host.
+func hostPool(pool JobPool, host string) string {
+	return string(pool) + "@" + host
+}
+
+// cancel removes a queued job from the queue. The job must be locked.
+func (j *JobManager) cancel(modelCtx *ModelContext, job Job) error {
+	position, err := j.queue.Position(job.ID)
+	if err != nil {
+		// The job just started.
+		return ErrNotQueued
+	}
+
+	if err := j.queue.Remove(job.ID); err != nil {
+		return ErrNotQueued
+	}
+
+	modelCtx.Log.DebugWithOwner(job.ID, "job canceled")
+
+	now := DateTime(time.Now())
+	job.Status = JobStatusCanceled
+	job.UpdatedAt = now
+	job.FinishedAt = &now
+	modelCtx.Nodes.MustStoreJob(job)
+	modelCtx.Subs.Publish(JobUpserted, job.ID)
+
+	atomic.AddInt64(&j.queuedCounter, -1)
+	atomic.AddInt64(&j.canceledCounter, 1)
+	j.publishMetrics(modelCtx)
+	j.publishQueue(modelCtx, position)
+
+	return nil
+}
+
+// requeue puts a job waiting to be retried back in the queue, unless it was
+// canceled in the meantime.
+func (j *JobManager) requeue(
+	modelCtx *ModelContext,
+	id string,
+	hi bool,
+	pools []string,
+	run func(),
+) {
+	requeued := false
+
+	j.retries.Delete(id)
+
+	// The job could have been canceled and deleted in the meantime.
+	err := modelCtx.Nodes.LockJob(id, func(job Job) {
+		if job.Status != JobStatusRetrying {
+			return
+		}
+
+		modelCtx.Log.DebugWithOwner(job.ID, "job queued for attempt %d", job.Attempt+1)
+
+		job.Status = JobStatusQueued
+		job.UpdatedAt = DateTime(time.Now())
+		modelCtx.Nodes.MustStoreJob(job)
+
+		atomic.AddInt64(&j.retryingCounter, -1)
+		atomic.AddInt64(&j.queuedCounter, 1)
+
+		j.queue.Push(id, hi, run, pools...)
+		requeued = true
+	})
+
+	if err != nil || !requeued {
+		return
+	}
+
+	j.publishMetrics(modelCtx)
+
+	if position, err := j.queue.Position(id); err == nil {
+		j.publishQueue(modelCtx, position)
+	}
+}
+
+// prune deletes the finished jobs that exceed the history limits.
+func (j *JobManager) prune(modelCtx *ModelContext) {
+	var evicted []Job
+
+	now := time.Now()
+
+	modelCtx.Nodes.MustLockSystem(modelCtx.SystemID, func(system System) {
+		var (
+			jobIDs   []string
+			finished int
+		)
+
+		// Job IDs are ordered newest first.
+		for _, id := range system.JobIDs {
+			job := modelCtx.Nodes.MustLoadJob(id)
+
+			if job.FinishedAt == nil {
+				jobIDs = append(jobIDs, id)
+				continue
+			}
+
+			age := now.Sub(time.Time(*job.FinishedAt))
+			evict := false
+
+			if job.Status == JobStatusFailed && j.failedHistoryMaxAge > 0 {
+				evict = age > j.failedHistoryMaxAge
+			} else {
+				finished++
+				evict = j.historyCap > 0 && finished > j.historyCap ||
+					j.historyMaxAge > 0 && age > j.historyMaxAge
+			}
+
+			if evict {
+				evicted = append(evicted, job)
+			} else {
+				jobIDs = append(jobIDs, id)
+			}
+		}
+
+		if len(evicted) > 0 {
+			system.JobIDs = jobIDs
+			modelCtx.Nodes.MustStoreSystem(system)
+		}
+	})
+
+	for _, job := range evicted {
+		switch job.Status {
+		case JobStatusDone:
+			atomic.AddInt64(&j.doneCounter, -1)
+		case JobStatusFailed:
+			atomic.AddInt64(&j.failedCounter, -1)
+		case JobStatusCanceled:
+			atomic.AddInt64(&j.canceledCounter, -1)
+		}
+
+		modelCtx.Nodes.MustDeleteJob(job.ID)
+		modelCtx.Subs.Publish(JobDeleted, job.ID)
+	}
+}
+
+// publishQueue publishes the queued jobs starting at the given position since
+// their position changed.
+func (j *JobManager) publishQueue(modelCtx *ModelContext, position int) {
+	ids := j.queue.IDs()
+
+	for i := position; i < len(ids); i++ {
+		modelCtx.Subs.Publish(JobUpserted, ids[i])
+	}
+}
+
 func (j *JobManager) publishMetrics(modelCtx *ModelContext) {
 	system := modelCtx.Nodes.MustLoadSystem(modelCtx.SystemID)
 
@@ -157,6 +581,8 @@ func (j *JobManager) publishMetrics(modelCtx *ModelContext) {
 		metrics.Running = int(atomic.LoadInt64(&j.runningCounter))
 		metrics.Done = int(atomic.LoadInt64(&j.doneCounter))
 		metrics.Failed = int(atomic.LoadInt64(&j.failedCounter))
+		metrics.Canceled = int(atomic.LoadInt64(&j.canceledCounter))
+		metrics.Retrying = int(atomic.LoadInt64(&j.retryingCounter))
 		modelCtx.Nodes.MustStoreJobMetrics(metrics)
 	})
 
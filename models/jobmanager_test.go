@@ -0,0 +1,195 @@
+// Copyright 2019 Stratumn
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"groundcontrol/pubsub"
+	"groundcontrol/relay"
+)
+
+func TestJobManager_prune(t *testing.T) {
+	modelCtx := &ModelContext{
+		Nodes:    &NodeManager{},
+		Subs:     pubsub.New(10),
+		SystemID: relay.EncodeID(NodeTypeSystem),
+	}
+
+	now := time.Now()
+	jobs := []struct {
+		status JobStatus
+		age    time.Duration
+	}{
+		{JobStatusRunning, 0},
+		{JobStatusDone, time.Minute},
+		{JobStatusFailed, time.Minute},
+		{JobStatusDone, 2 * time.Minute},
+		{JobStatusCanceled, 3 * time.Minute},
+		{JobStatusFailed, 2 * time.Hour},
+		{JobStatusDone, 2 * time.Hour},
+		{JobStatusFailed, 4 * time.Hour},
+	}
+
+	system := System{ID: modelCtx.SystemID}
+
+	for i, v := range jobs {
+		job := Job{
+			ID:     relay.EncodeID(NodeTypeJob, fmt.Sprint(i)),
+			Status: v.status,
+		}
+
+		if v.status != JobStatusRunning {
+			finishedAt := DateTime(now.Add(-v.age))
+			job.FinishedAt = &finishedAt
+		}
+
+		modelCtx.Nodes.MustStoreJob(job)
+		system.JobIDs = append(system.JobIDs, job.ID)
+	}
+
+	modelCtx.Nodes.MustStoreSystem(system)
+
+	j := NewJobManager(nil, 2, time.Hour, 3*time.Hour)
+	j.doneCounter = 3
+	j.failedCounter = 3
+	j.canceledCounter = 1
+	j.prune(modelCtx)
+
+	assert.Equal(t, []string{
+		relay.EncodeID(NodeTypeJob, "0"),
+		relay.EncodeID(NodeTypeJob, "1"),
+		relay.EncodeID(NodeTypeJob, "2"),
+		relay.EncodeID(NodeTypeJob, "3"),
+		relay.EncodeID(NodeTypeJob, "5"),
+	}, modelCtx.Nodes.MustLoadSystem(modelCtx.SystemID).JobIDs)
+
+	_, err := modelCtx.Nodes.LoadJob(relay.EncodeID(NodeTypeJob, "4"))
+	assert.Equal(t, ErrNotFound, err)
+
+	assert.Equal(t, int64(2), j.doneCounter)
+	assert.Equal(t, int64(2), j.failedCounter)
+	assert.Equal(t, int64(0), j.canceledCounter)
+}
+
+func TestJobManager_prune_canceledWhileRetrying(t *testing.T) {
+	nodes := &NodeManager{}
+	subs := pubsub.New(100)
+	systemID := relay.EncodeID(NodeTypeSystem)
+	jobMetricsID := relay.EncodeID(NodeTypeJobMetrics)
+	nodes.MustStoreJobMetrics(JobMetrics{ID: jobMetricsID})
+	nodes.MustStoreSystem(System{ID: systemID, JobMetricsID: jobMetricsID})
+
+	j := NewJobManager([]JobConcurrency{{Pool: JobPoolDefault, Concurrency: 1}}, 1, 0, 0)
+	modelCtx := &ModelContext{
+		Nodes:    nodes,
+		Subs:     subs,
+		Jobs:     j,
+		Log:      NewLogger(nodes, subs, 10, LogLevelError, systemID),
+		SystemID: systemID,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go j.Work(ctx)
+
+	retry := &RetryPolicy{MaxAttempts: 2, BaseDelay: 50 * time.Millisecond}
+	fail := func(context.Context, *ProgressReporter) error { return errors.New("fail") }
+	succeed := func(context.Context, *ProgressReporter) error { return nil }
+
+	waitStatus := func(id string, status JobStatus) {
+		for i := 0; i < 100; i++ {
+			if nodes.MustLoadJob(id).Status == status {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+		t.Fatalf("job didn't reach status %s", status)
+	}
+
+	retrying := j.Add(modelCtx, "retrying", systemID, JobPoolDefault, "", JobPriorityNormal, retry, fail)
+	waitStatus(retrying, JobStatusRetrying)
+	assert.NoError(t, j.Stop(modelCtx, retrying))
+
+	done := j.Add(modelCtx, "done", systemID, JobPoolDefault, "", JobPriorityNormal, nil, succeed)
+	waitStatus(done, JobStatusDone)
+
+	// The job is pruned right after the other job is done.
+	for i := 0; i < 100; i++ {
+		if _, err := nodes.LoadJob(retrying); err == ErrNotFound {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	_, err := nodes.LoadJob(retrying)
+	assert.Equal(t, ErrNotFound, err)
+
+	// The retry timer would panic if it fired.
+	time.Sleep(2 * retry.BaseDelay)
+
+	// Requeuing a deleted job is a NOP.
+	j.requeue(modelCtx, retrying, false, nil, func() {})
+	assert.Empty(t, j.queue.IDs())
+}
+
+func TestJobManager_SetConcurrency(t *testing.T) {
+	j := NewJobManager([]JobConcurrency{{
+		Pool:            JobPoolGit,
+		Concurrency:     4,
+		HostConcurrency: 2,
+	}, {
+		Pool:        JobPoolDefault,
+		Concurrency: 2,
+	}}, 0, 0, 0)
+
+	assert.Equal(t, []JobConcurrency{
+		{Pool: JobPoolDefault, Concurrency: 2},
+		{Pool: JobPoolGit, Concurrency: 4, HostConcurrency: 2},
+	}, j.Concurrency())
+
+	assert.Equal(t, []string{"GIT", "GIT@github.com"}, j.pools(JobPoolGit, "github.com"))
+	assert.Equal(t, 2, j.queue.Limit("GIT@github.com"))
+
+	zero, one, negative := 0, 1, -1
+
+	_, err := j.SetConcurrency(JobPoolGit, &zero, nil)
+	assert.Equal(t, ErrConcurrency, err)
+
+	_, err = j.SetConcurrency(JobPoolGit, nil, &negative)
+	assert.Equal(t, ErrHostNegative, err)
+
+	_, err = j.SetConcurrency(JobPool("NOPE"), &one, nil)
+	assert.Equal(t, ErrJobPool, err)
+
+	c, err := j.SetConcurrency(JobPoolGit, nil, &one)
+	assert.NoError(t, err)
+	assert.Equal(t, JobConcurrency{Pool: JobPoolGit, Concurrency: 4, HostConcurrency: 1}, c)
+	assert.Equal(t, 4, j.queue.Limit("GIT"))
+	assert.Equal(t, 1, j.queue.Limit("GIT@github.com"))
+
+	c, err = j.SetConcurrency(JobPoolRun, &one, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, JobConcurrency{Pool: JobPoolRun, Concurrency: 1}, c)
+	assert.Equal(t, []string{"RUN"}, j.pools(JobPoolRun, ""))
+	assert.Equal(t, 1, j.queue.Limit("RUN"))
+}
@@ -18,6 +18,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sync"
 
 	yaml "gopkg.in/yaml.v2"
 
@@ -26,9 +27,13 @@ import (
 )
 
 // KeysConfig contains all the data in a YAML keys config file.
+// It is safe to use concurrently through its methods.
 type KeysConfig struct {
 	Filename string            `json:"-" yaml:"-"`
 	Keys     map[string]string `json:"keys" yaml:"keys"`
+
+	// mu guards Keys, which Git jobs read while keys are being edited.
+	mu sync.RWMutex
 }
 
 // UpsertNodes upserts nodes for the content of the keys config.
@@ -38,10 +43,17 @@ func (c *KeysConfig) UpsertNodes(
 	subs *pubsub.PubSub,
 	userID string,
 ) error {
+	c.mu.RLock()
+	keys := make(map[string]string, len(c.Keys))
+	for name, value := range c.Keys {
+		keys[name] = value
+	}
+	c.mu.RUnlock()
+
 	return nodes.MustLockUserE(userID, func(user User) error {
 		var keyIDs []string
 
-		for name, value := range c.Keys {
+		for name, value := range keys {
 			key := Key{
 				ID:    relay.EncodeID(NodeTypeKey, name),
 				Name:  name,
@@ -78,7 +90,9 @@ func (c *KeysConfig) UpsertKey(
 	}
 
 	nodes.MustLockUser(userID, func(user User) {
+		c.mu.Lock()
 		c.Keys[input.Name] = input.Value
+		c.mu.Unlock()
 
 		exists := false
 		for _, keyID := range user.KeyIDs {
@@ -119,7 +133,9 @@ func (c *KeysConfig) DeleteKey(
 				}
 			}
 
+			c.mu.Lock()
 			delete(c.Keys, key.Name)
+			c.mu.Unlock()
 
 			nodes.MustDeleteKey(id)
 			nodes.MustStoreUser(user)
@@ -128,9 +144,23 @@ func (c *KeysConfig) DeleteKey(
 	})
 }
 
+// lookup returns the value of a key.
+func (c *KeysConfig) lookup(name string) (string, error) {
+	c.mu.RLock()
+	value, ok := c.Keys[name]
+	c.mu.RUnlock()
+	if !ok {
+		return "", ErrKeyNotFound
+	}
+
+	return value, nil
+}
+
 // Save saves the config to disk, overwriting the file if it exists.
-func (c KeysConfig) Save() error {
+func (c *KeysConfig) Save() error {
+	c.mu.RLock()
 	bytes, err := yaml.Marshal(c)
+	c.mu.RUnlock()
 	if err != nil {
 		return err
 	}
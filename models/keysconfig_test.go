@@ -0,0 +1,56 @@
+// Copyright 2019 Stratumn
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"groundcontrol/pubsub"
+	"groundcontrol/relay"
+)
+
+func TestKeysConfig_concurrent(t *testing.T) {
+	nodes := &NodeManager{}
+	subs := pubsub.New(10)
+	userID := relay.EncodeID(NodeTypeUser)
+	nodes.MustStoreUser(User{ID: userID})
+
+	keys := &KeysConfig{Keys: map[string]string{"TOKEN": "secret"}}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		for i := 0; i < 100; i++ {
+			name := fmt.Sprint("KEY_", i)
+			id := keys.UpsertKey(nodes, subs, userID, KeyInput{Name: name, Value: "value"})
+			assert.NoError(t, keys.DeleteKey(nodes, subs, userID, id))
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		value, err := keys.lookup("TOKEN")
+		assert.NoError(t, err)
+		assert.Equal(t, "secret", value)
+	}
+
+	wg.Wait()
+}
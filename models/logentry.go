@@ -34,5 +34,8 @@ func (l LogEntry) Owner(ctx context.Context) Node {
 		return nil
 	}
 
-	return GetModelContext(ctx).Nodes.MustLoad(l.OwnerID)
+	// The owner might have been deleted, for instance if it is an old job.
+	node, _ := GetModelContext(ctx).Nodes.Load(l.OwnerID)
+
+	return node
 }
@@ -24,10 +24,12 @@ const (
 	KeyUpserted           = "KEY_UPSERTED"
 	KeyDeleted            = "KEY_DELETED"
 	JobUpserted           = "JOB_UPSERTED"
+	JobDeleted            = "JOB_DELETED"
 	JobMetricsUpdated     = "JOB_METRICS_UPDATED"
 	ProcessGroupUpserted  = "PROCESS_GROUP_UPSERTED"
 	ProcessUpserted       = "PROCESS_UPSERTED"
 	ProcessMetricsUpdated = "PROCESS_METRICS_UPDATED"
+	ProcessOutputAdded    = "PROCESS_OUTPUT_ADDED"
 	LogEntryAdded         = "LOG_ENTRY_ADDED"
 	LogMetricsUpdated     = "LOG_METRICS_UPDATED"
 )
@@ -0,0 +1,34 @@
+// Copyright 2019 Stratumn
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import "context"
+
+// OutputLine represents a line written by a process.
+type OutputLine struct {
+	ID        string       `json:"id"`
+	Stream    OutputStream `json:"stream"`
+	CreatedAt DateTime     `json:"createdAt"`
+	Text      string       `json:"text"`
+	ProcessID string       `json:"processId"`
+}
+
+// IsNode tells gqlgen that it implements Node.
+func (OutputLine) IsNode() {}
+
+// Process returns the Process associated with the OutputLine.
+func (l OutputLine) Process(ctx context.Context) Process {
+	return GetModelContext(ctx).Nodes.MustLoadProcess(l.ProcessID)
+}
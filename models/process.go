@@ -14,15 +14,120 @@
 
 package models
 
-import "context"
+import (
+	"context"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Process defaults.
+const (
+	// DefaultStopSignal is the signal sent to stop a process if none is specified.
+	DefaultStopSignal = "SIGINT"
+
+	// DefaultStopTimeout is how long to wait after the stop signal before sending SIGTERM if not specified.
+	DefaultStopTimeout = 10 * time.Second
+
+	// DefaultKillTimeout is how long to wait after SIGTERM before sending SIGKILL if not specified.
+	DefaultKillTimeout = 5 * time.Second
+
+	// DefaultRestartDelay is how long to wait before the first restart if not specified.
+	DefaultRestartDelay = time.Second
+
+	// MaxRestartDelay is the maximum time to wait before restarting a process.
+	MaxRestartDelay = time.Minute
+
+	// StableRunDuration is how long a process must run before exiting for its
+	// restart count to be reset, so that only crashes in a row count towards
+	// the backoff and the maximum number of restarts.
+	StableRunDuration = 2 * MaxRestartDelay
+)
+
+var signals = map[string]syscall.Signal{
+	"SIGHUP":  syscall.SIGHUP,
+	"SIGINT":  syscall.SIGINT,
+	"SIGQUIT": syscall.SIGQUIT,
+	"SIGKILL": syscall.SIGKILL,
+	"SIGUSR1": syscall.SIGUSR1,
+	"SIGUSR2": syscall.SIGUSR2,
+	"SIGTERM": syscall.SIGTERM,
+}
+
+// signalName returns the name of a signal, such as SIGTERM.
+func signalName(signal syscall.Signal) string {
+	for name, sig := range signals {
+		if sig == signal {
+			return name
+		}
+	}
+
+	return signal.String()
+}
+
+// ParseSignal returns the signal with the given name, such as SIGTERM or TERM.
+func ParseSignal(name string) (syscall.Signal, error) {
+	name = strings.ToUpper(name)
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+
+	signal, ok := signals[name]
+	if !ok {
+		return 0, ErrSignal
+	}
+
+	return signal, nil
+}
+
+var restartPolicies = map[string]RestartPolicy{
+	"":           RestartPolicyNever,
+	"never":      RestartPolicyNever,
+	"on-failure": RestartPolicyOnFailure,
+	"always":     RestartPolicyAlways,
+}
+
+// ParseRestartPolicy returns the restart policy with the given name,
+// which can be "never", "on-failure" or "always".
+func ParseRestartPolicy(name string) (RestartPolicy, error) {
+	policy, ok := restartPolicies[strings.ToLower(name)]
+	if !ok {
+		return "", ErrRestartPolicy
+	}
+
+	return policy, nil
+}
 
 // Process represents a process in the app.
 type Process struct {
 	ID      string `json:"id"`
+	Name    string `json:"name"`
 	Command string `json:"command"`
 	// Env is the environment of the process.
 	// Each entry is of the form "key=value".
-	Env            []string      `json:"env"`
+	Env           []string      `json:"env"`
+	StopSignal    string        `json:"stopSignal"`
+	StopTimeout   Duration      `json:"stopTimeout"`
+	KillTimeout   Duration      `json:"killTimeout"`
+	RestartPolicy RestartPolicy `json:"restartPolicy"`
+	MaxRestarts   int           `json:"maxRestarts"`
+	RestartDelay  Duration      `json:"restartDelay"`
+	RestartCount  int           `json:"restartCount"`
+	// ReadinessProbe tells when the process is ready.
+	ReadinessProbe *ReadinessProbe `json:"readinessProbe"`
+	// LastExitCode is the exit code of the last run that exited. It is kept
+	// while the process runs again, so ExitedAt tells whether it is current.
+	LastExitCode *int `json:"lastExitCode"`
+	// Pid is the ID of the last started operating system process.
+	Pid       *int      `json:"pid"`
+	StartedAt *DateTime `json:"startedAt"`
+	ExitedAt  *DateTime `json:"exitedAt"`
+	// CPUPercent and RSS are the resource usage of the process group while running.
+	CPUPercent *float64 `json:"cpuPercent"`
+	RSS        *int     `json:"rss"`
+	TTY        bool     `json:"tty"`
+	// OutputLineIDs contains the most recent output lines, oldest first.
+	OutputLineIDs  []string      `json:"outputLineIds"`
 	ProcessGroupID string        `json:"processGroupId"`
 	ProjectID      string        `json:"projectId"`
 	Status         ProcessStatus `json:"status"`
@@ -31,6 +136,21 @@ type Process struct {
 // IsNode tells gqlgen that it implements Node.
 func (Process) IsNode() {}
 
+// IsStarted returns whether the process was started and isn't stopping or stopped.
+func (p Process) IsStarted() bool {
+	switch p.Status {
+	case ProcessStatusStarting, ProcessStatusRunning, ProcessStatusReady:
+		return true
+	}
+
+	return false
+}
+
+// Output returns the most recent output lines of the Process.
+func (p Process) Output(ctx context.Context, after *string, first *int) (OutputLineConnection, error) {
+	return PaginateOutputLineIDSliceContext(ctx, p.OutputLineIDs, after, nil, first, nil)
+}
+
 // ProcessGroup returns the ProcessGroup associated with the Process.
 func (p Process) ProcessGroup(ctx context.Context) ProcessGroup {
 	return GetModelContext(ctx).Nodes.MustLoadProcessGroup(p.ProcessGroupID)
@@ -40,3 +160,52 @@ func (p Process) ProcessGroup(ctx context.Context) ProcessGroup {
 func (p Process) Project(ctx context.Context) Project {
 	return GetModelContext(ctx).Nodes.MustLoadProject(p.ProjectID)
 }
+
+// ranStably tells whether the last run of the process lasted at least
+// StableRunDuration.
+func (p Process) ranStably() bool {
+	if p.StartedAt == nil || p.ExitedAt == nil {
+		return false
+	}
+
+	return time.Time(*p.ExitedAt).Sub(time.Time(*p.StartedAt)) >= StableRunDuration
+}
+
+// restartDelay tells whether the process should be restarted after exiting
+// and how long to wait before doing so. Restarts before a stable run aren't
+// counted.
+func (p Process) restartDelay() (time.Duration, bool) {
+	switch p.RestartPolicy {
+	case RestartPolicyAlways:
+	case RestartPolicyOnFailure:
+		if p.Status != ProcessStatusFailed {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+
+	restartCount := p.RestartCount
+	if p.ranStably() {
+		restartCount = 0
+	}
+
+	if p.MaxRestarts > 0 && restartCount >= p.MaxRestarts {
+		return 0, false
+	}
+
+	delay := time.Duration(p.RestartDelay)
+	if delay <= 0 {
+		delay = DefaultRestartDelay
+	}
+
+	for i := 0; i < restartCount && delay < MaxRestartDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > MaxRestartDelay {
+		delay = MaxRestartDelay
+	}
+
+	return delay, true
+}
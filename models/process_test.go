@@ -0,0 +1,102 @@
+// Copyright 2019 Stratumn
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcess_restartDelay(t *testing.T) {
+	now := time.Now()
+	startedAt := DateTime(now)
+	crashedAt := DateTime(now.Add(time.Second))
+	stableExitedAt := DateTime(now.Add(StableRunDuration))
+
+	tests := []struct {
+		name        string
+		process     Process
+		wantDelay   time.Duration
+		wantRestart bool
+	}{{
+		"never",
+		Process{RestartPolicy: RestartPolicyNever, Status: ProcessStatusFailed},
+		0,
+		false,
+	}, {
+		"on failure done",
+		Process{RestartPolicy: RestartPolicyOnFailure, Status: ProcessStatusDone},
+		0,
+		false,
+	}, {
+		"on failure failed",
+		Process{RestartPolicy: RestartPolicyOnFailure, Status: ProcessStatusFailed},
+		DefaultRestartDelay,
+		true,
+	}, {
+		"always backoff",
+		Process{
+			RestartPolicy: RestartPolicyAlways,
+			Status:        ProcessStatusDone,
+			RestartDelay:  Duration(time.Second),
+			RestartCount:  3,
+		},
+		8 * time.Second,
+		true,
+	}, {
+		"max delay",
+		Process{
+			RestartPolicy: RestartPolicyAlways,
+			Status:        ProcessStatusDone,
+			RestartCount:  100,
+		},
+		MaxRestartDelay,
+		true,
+	}, {
+		"max restarts",
+		Process{
+			RestartPolicy: RestartPolicyAlways,
+			Status:        ProcessStatusFailed,
+			MaxRestarts:   2,
+			RestartCount:  2,
+			StartedAt:     &startedAt,
+			ExitedAt:      &crashedAt,
+		},
+		0,
+		false,
+	}, {
+		"stable run",
+		Process{
+			RestartPolicy: RestartPolicyAlways,
+			Status:        ProcessStatusFailed,
+			RestartDelay:  Duration(time.Second),
+			MaxRestarts:   2,
+			RestartCount:  2,
+			StartedAt:     &startedAt,
+			ExitedAt:      &stableExitedAt,
+		},
+		time.Second,
+		true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delay, restart := tt.process.restartDelay()
+			assert.Equal(t, tt.wantRestart, restart)
+			assert.Equal(t, tt.wantDelay, delay)
+		})
+	}
+}
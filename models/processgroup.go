@@ -51,11 +51,15 @@ func (p ProcessGroup) Status(ctx context.Context) ProcessStatus {
 	for _, id := range p.ProcessIDs {
 		node := nodes.MustLoadProcess(id)
 
-		if node.Status == ProcessStatusFailed {
+		switch node.Status {
+		case ProcessStatusFailed:
 			return ProcessStatusFailed
-		}
-		if node.Status == ProcessStatusRunning {
-			status = ProcessStatusRunning
+		case ProcessStatusStarting:
+			status = ProcessStatusStarting
+		case ProcessStatusRunning, ProcessStatusReady:
+			if status != ProcessStatusStarting {
+				status = ProcessStatusRunning
+			}
 		}
 	}
 
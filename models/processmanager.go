@@ -19,28 +19,56 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
+	"regexp"
 	"sync"
 	"sync/atomic"
 	"syscall"
 	"time"
 
+	"github.com/kr/pty"
+
 	"groundcontrol/relay"
 )
 
 // ProcessManager manages creating and running jobs.
 type ProcessManager struct {
-	commands sync.Map
-	lastID   uint64
+	commands  sync.Map
+	terminals sync.Map
+	lastID    uint64
+	outputCap int
+
+	restartsMu    sync.Mutex
+	restarts      map[string]uint64
+	lastRestartID uint64
+	closed        int32
 
 	runningCounter int64
 	doneCounter    int64
 	failedCounter  int64
+
+	usageMu    sync.Mutex
+	usages     map[string]processUsage
+	cpuPercent float64
+	rss        int64
+}
+
+// processUsage is the last resource usage sample of a process.
+type processUsage struct {
+	cmd       *exec.Cmd
+	cpuTicks  uint64
+	sampledAt time.Time
 }
 
 // NewProcessManager creates a ProcessManager.
-func NewProcessManager() *ProcessManager {
-	return &ProcessManager{}
+// The output capacity is the number of output lines kept for each process.
+func NewProcessManager(outputCap int) *ProcessManager {
+	return &ProcessManager{
+		outputCap: outputCap,
+		restarts:  map[string]uint64{},
+		usages:    map[string]processUsage{},
+	}
 }
 
 // CreateGroup creates a new ProcessGroup and returns its ID.
@@ -73,10 +101,10 @@ func (p *ProcessManager) CreateGroup(ctx context.Context, taskID string) string
 	return id
 }
 
-// Run launches a new Process and adds it to a ProcessGroup.
+// Run launches a new Process for a background command and adds it to a ProcessGroup.
 func (p *ProcessManager) Run(
 	ctx context.Context,
-	command string,
+	command Command,
 	env []string,
 	processGroupID string,
 	projectID string,
@@ -88,10 +116,24 @@ func (p *ProcessManager) Run(
 		fmt.Sprint(atomic.AddUint64(&p.lastID, 1)),
 	)
 
+	name := command.Name
+	if name == "" {
+		name = command.Command
+	}
+
 	process := Process{
 		ID:             id,
-		Command:        command,
-		Env:            env,
+		Name:           name,
+		Command:        command.Command,
+		Env:            append(append([]string(nil), env...), command.Env...),
+		StopSignal:     command.StopSignal,
+		StopTimeout:    command.StopTimeout,
+		KillTimeout:    command.KillTimeout,
+		RestartPolicy:  command.RestartPolicy,
+		MaxRestarts:    command.MaxRestarts,
+		RestartDelay:   command.RestartDelay,
+		ReadinessProbe: command.ReadinessProbe,
+		TTY:            command.TTY,
 		ProcessGroupID: processGroupID,
 		ProjectID:      projectID,
 	}
@@ -113,13 +155,18 @@ func (p *ProcessManager) Start(ctx context.Context, processID string) error {
 
 	err := modelCtx.Nodes.LockProcessE(processID, func(process Process) error {
 		switch process.Status {
-		case ProcessStatusRunning, ProcessStatusStopping:
+		case ProcessStatusStarting, ProcessStatusRunning, ProcessStatusReady, ProcessStatusStopping:
 			return ErrNotStopped
 		case ProcessStatusDone:
 			atomic.AddInt64(&p.doneCounter, -1)
 		case ProcessStatusFailed:
 			atomic.AddInt64(&p.failedCounter, -1)
 		}
+
+		p.cancelRestart(processID)
+		process.RestartCount = 0
+		modelCtx.Nodes.MustStoreProcess(process)
+
 		return nil
 	})
 	if err != nil {
@@ -136,7 +183,12 @@ func (p *ProcessManager) Stop(ctx context.Context, processID string) error {
 	modelCtx := GetModelContext(ctx)
 
 	return modelCtx.Nodes.LockProcessE(processID, func(process Process) error {
-		if process.Status != ProcessStatusRunning {
+		if !process.IsStarted() {
+			if p.cancelRestart(processID) {
+				modelCtx.Log.DebugWithOwner(processID, "process restart canceled")
+				return nil
+			}
+
 			return ErrNotRunning
 		}
 
@@ -152,20 +204,132 @@ func (p *ProcessManager) Stop(ctx context.Context, processID string) error {
 		}
 		cmd := actual.(*exec.Cmd)
 
+		signal := syscall.SIGINT
+		if process.StopSignal != "" {
+			var err error
+			if signal, err = ParseSignal(process.StopSignal); err != nil {
+				return err
+			}
+		}
+
+		pgid, err := syscall.Getpgid(cmd.Process.Pid)
+		if err != nil {
+			return err
+		}
+
+		modelCtx.Log.InfoWithOwner(processID, "sending %s to process", signalName(signal))
+
+		if err := syscall.Kill(-pgid, signal); err != nil {
+			return err
+		}
+
+		go p.escalate(ctx, process, cmd, pgid, signal)
+
+		return nil
+	})
+}
+
+// Kill kills a running or stopping process immediately.
+func (p *ProcessManager) Kill(ctx context.Context, processID string) error {
+	modelCtx := GetModelContext(ctx)
+
+	return modelCtx.Nodes.LockProcessE(processID, func(process Process) error {
+		if !process.IsStarted() && process.Status != ProcessStatusStopping {
+			if p.cancelRestart(processID) {
+				modelCtx.Log.DebugWithOwner(processID, "process restart canceled")
+				return nil
+			}
+
+			return ErrNotRunning
+		}
+
+		if process.Status != ProcessStatusStopping {
+			process.Status = ProcessStatusStopping
+			modelCtx.Nodes.MustStoreProcess(process)
+
+			modelCtx.Subs.Publish(ProcessUpserted, processID)
+			modelCtx.Subs.Publish(ProcessGroupUpserted, process.ProcessGroupID)
+		}
+
+		actual, ok := p.commands.Load(processID)
+		if !ok {
+			panic("command not found")
+		}
+		cmd := actual.(*exec.Cmd)
+
 		pgid, err := syscall.Getpgid(cmd.Process.Pid)
 		if err != nil {
 			return err
 		}
 
-		return syscall.Kill(-pgid, syscall.SIGINT)
+		modelCtx.Log.WarningWithOwner(processID, "killing process")
+
+		return syscall.Kill(-pgid, syscall.SIGKILL)
 	})
 }
 
+// escalate sends SIGTERM then SIGKILL to a process group if the command is
+// still running after the stop and kill timeouts.
+func (p *ProcessManager) escalate(
+	ctx context.Context,
+	process Process,
+	cmd *exec.Cmd,
+	pgid int,
+	signal syscall.Signal,
+) {
+	modelCtx := GetModelContext(ctx)
+
+	steps := []struct {
+		signal  syscall.Signal
+		timeout time.Duration
+	}{
+		{syscall.SIGTERM, time.Duration(process.StopTimeout)},
+		{syscall.SIGKILL, time.Duration(process.KillTimeout)},
+	}
+
+	for _, step := range steps {
+		if signal == syscall.SIGKILL {
+			return
+		}
+
+		if signal == step.signal {
+			continue
+		}
+
+		<-time.After(step.timeout)
+
+		// The process could have exited or been restarted in the meantime.
+		if actual, ok := p.commands.Load(process.ID); !ok || actual.(*exec.Cmd) != cmd {
+			return
+		}
+
+		modelCtx.Log.WarningWithOwner(
+			process.ID,
+			"process didn't stop after %s, sending %s",
+			step.timeout,
+			signalName(step.signal),
+		)
+
+		if err := syscall.Kill(-pgid, step.signal); err != nil {
+			modelCtx.Log.ErrorWithOwner(process.ID, "failed to signal process because %s", err.Error())
+			return
+		}
+
+		signal = step.signal
+	}
+}
+
 // Clean terminates all running processes.
 func (p *ProcessManager) Clean(ctx context.Context) {
 	modelCtx := GetModelContext(ctx)
 	waitGroup := sync.WaitGroup{}
 
+	atomic.StoreInt32(&p.closed, 1)
+
+	p.restartsMu.Lock()
+	p.restarts = map[string]uint64{}
+	p.restartsMu.Unlock()
+
 	p.commands.Range(func(k, _ interface{}) bool {
 		processID := k.(string)
 
@@ -219,18 +383,60 @@ func (p *ProcessManager) exec(ctx context.Context, id string) {
 			project.Branch,
 		)
 
-		stdout := CreateLineWriter(modelCtx.Log.InfoWithOwner, project.ID)
-		stderr := CreateLineWriter(modelCtx.Log.WarningWithOwner, project.ID)
-		cmd := exec.Command("bash", "-l", "-c", process.Command)
+		var cmd *exec.Cmd
+
+		writeStdout := p.recordOutput(ctx, id, OutputStreamStdout, modelCtx.Log.InfoWithOwner)
+		writeStderr := p.recordOutput(ctx, id, OutputStreamStderr, modelCtx.Log.WarningWithOwner)
+
+		if process.ReadinessProbe != nil {
+			// Already validated when the config was loaded.
+			re, _ := process.ReadinessProbe.LogRegexp()
+
+			if re != nil {
+				writeStdout = p.readyOnMatch(ctx, id, &cmd, re, writeStdout)
+				writeStderr = p.readyOnMatch(ctx, id, &cmd, re, writeStderr)
+			}
+		}
+
+		stdout := CreateLineWriter(writeStdout, project.ID)
+		stderr := CreateLineWriter(writeStderr, project.ID)
+		cmd = exec.Command("bash", "-l", "-c", process.Command)
 		cmd.Dir = dir
 		cmd.Env = process.Env
-		cmd.Stdout = stdout
-		cmd.Stderr = stderr
-		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
 
-		err := cmd.Start()
+		var (
+			terminal *Terminal
+			err      error
+		)
+
+		if process.TTY {
+			// The pseudo-terminal starts a new session, which also creates
+			// a new process group.
+			var file *os.File
+			if file, err = pty.Start(cmd); err == nil {
+				terminal = newTerminal(file)
+				go terminal.copyOutput(stdout)
+			}
+		} else {
+			cmd.Stdout = stdout
+			cmd.Stderr = stderr
+			cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+			err = cmd.Start()
+		}
+
+		process.ExitedAt = nil
+		process.CPUPercent = nil
+		process.RSS = nil
+
 		if err == nil {
+			pid := cmd.Process.Pid
+			startedAt := DateTime(time.Now())
+			process.Pid = &pid
+			process.StartedAt = &startedAt
 			process.Status = ProcessStatusRunning
+			if process.ReadinessProbe != nil {
+				process.Status = ProcessStatusStarting
+			}
 			atomic.AddInt64(&p.runningCounter, 1)
 		} else {
 			process.Status = ProcessStatusFailed
@@ -252,11 +458,36 @@ func (p *ProcessManager) exec(ctx context.Context, id string) {
 		modelCtx.Log.DebugWithOwner(project.ID, "process is running")
 		p.commands.Store(id, cmd)
 
+		if terminal != nil {
+			p.terminals.Store(id, terminal)
+		}
+
+		cancelProbe := func() {}
+
+		if process.ReadinessProbe != nil {
+			// The probe outlives the request that started the process, so it
+			// is canceled when the process exits instead.
+			var probeCtx context.Context
+			probeCtx, cancelProbe = context.WithCancel(WithModelContext(context.Background(), modelCtx))
+			go p.probe(probeCtx, id, cmd, *process.ReadinessProbe)
+		}
+
 		go func() {
 			err := cmd.Wait()
+			cancelProbe()
+			restartDelay, restart := time.Duration(0), false
 
 			modelCtx.Nodes.MustLockProcess(id, func(process Process) {
 				p.commands.Delete(id)
+				p.terminals.Delete(id)
+
+				stopped := process.Status == ProcessStatusStopping
+				exitCode := cmd.ProcessState.ExitCode()
+				exitedAt := DateTime(time.Now())
+				process.LastExitCode = &exitCode
+				process.ExitedAt = &exitedAt
+				process.CPUPercent = nil
+				process.RSS = nil
 
 				if err == nil {
 					process.Status = ProcessStatusDone
@@ -268,6 +499,14 @@ func (p *ProcessManager) exec(ctx context.Context, id string) {
 					modelCtx.Log.ErrorWithOwner(project.ID, "process failed because %s", err.Error())
 				}
 
+				if !stopped && atomic.LoadInt32(&p.closed) == 0 {
+					restartDelay, restart = process.restartDelay()
+				}
+
+				if process.ranStably() {
+					process.RestartCount = 0
+				}
+
 				atomic.AddInt64(&p.runningCounter, -1)
 				modelCtx.Nodes.MustStoreProcess(process)
 			})
@@ -276,13 +515,366 @@ func (p *ProcessManager) exec(ctx context.Context, id string) {
 			modelCtx.Subs.Publish(ProcessGroupUpserted, process.ProcessGroupID)
 			p.publishMetrics(ctx)
 
+			if terminal != nil {
+				terminal.close()
+			}
+
 			stdout.Close()
 			stderr.Close()
+
+			if restart {
+				modelCtx.Log.InfoWithOwner(project.ID, "restarting process in %s", restartDelay)
+				p.scheduleRestart(ctx, id, restartDelay)
+			}
 		}()
 	})
 }
 
+// Terminal returns the pseudo-terminal of a running process started with TTY.
+func (p *ProcessManager) Terminal(processID string) (*Terminal, error) {
+	if _, ok := p.commands.Load(processID); !ok {
+		return nil, ErrNotRunning
+	}
+
+	actual, ok := p.terminals.Load(processID)
+	if !ok {
+		return nil, ErrNoTerminal
+	}
+
+	return actual.(*Terminal), nil
+}
+
+// WaitReady blocks until a process is ready.
+// It returns an error if the process stops before being ready.
+func (p *ProcessManager) WaitReady(ctx context.Context, processID string) error {
+	modelCtx := GetModelContext(ctx)
+
+	subsCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	upsertedCh := make(chan struct{}, 1)
+
+	modelCtx.Subs.Subscribe(subsCtx, ProcessUpserted, 0, func(msg interface{}) {
+		if msg.(string) != processID {
+			return
+		}
+
+		select {
+		case upsertedCh <- struct{}{}:
+		default:
+		}
+	})
+
+	for {
+		switch modelCtx.Nodes.MustLoadProcess(processID).Status {
+		case ProcessStatusRunning, ProcessStatusReady:
+			return nil
+		case ProcessStatusStarting:
+		default:
+			return ErrNotReady
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-upsertedCh:
+		}
+	}
+}
+
+// probe checks the readiness of a process periodically until it is ready.
+// The process is stopped if it isn't ready before the timeout.
+func (p *ProcessManager) probe(
+	ctx context.Context,
+	processID string,
+	cmd *exec.Cmd,
+	probe ReadinessProbe,
+) {
+	modelCtx := GetModelContext(ctx)
+	timeout := time.After(time.Duration(probe.Timeout))
+	ticker := time.NewTicker(time.Duration(probe.Interval))
+	defer ticker.Stop()
+
+	for {
+		if actual, ok := p.commands.Load(processID); !ok || actual.(*exec.Cmd) != cmd {
+			return
+		}
+
+		if modelCtx.Nodes.MustLoadProcess(processID).Status != ProcessStatusStarting {
+			return
+		}
+
+		if probe.Check(ctx) {
+			p.markReady(ctx, processID, cmd)
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-timeout:
+			modelCtx.Log.ErrorWithOwner(processID, "process wasn't ready after %s, stopping it", time.Duration(probe.Timeout))
+
+			if err := p.Stop(ctx, processID); err != nil {
+				modelCtx.Log.ErrorWithOwner(processID, "failed to stop process because %s", err.Error())
+			}
+
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// recordOutput wraps a log function to also add the lines to the output of the process.
+func (p *ProcessManager) recordOutput(
+	ctx context.Context,
+	processID string,
+	stream OutputStream,
+	write func(ownerID, message string, a ...interface{}) string,
+) func(ownerID, message string, a ...interface{}) string {
+	return func(ownerID, message string, a ...interface{}) string {
+		p.addOutput(ctx, processID, stream, message)
+		return write(ownerID, message, a...)
+	}
+}
+
+// addOutput adds a line to the output of a process, evicting the oldest lines
+// if the capacity is exceeded.
+func (p *ProcessManager) addOutput(ctx context.Context, processID string, stream OutputStream, text string) {
+	modelCtx := GetModelContext(ctx)
+
+	line := OutputLine{
+		ID: relay.EncodeID(
+			NodeTypeOutputLine,
+			fmt.Sprint(atomic.AddUint64(&p.lastID, 1)),
+		),
+		Stream:    stream,
+		CreatedAt: DateTime(time.Now()),
+		Text:      text,
+		ProcessID: processID,
+	}
+	modelCtx.Nodes.MustStoreOutputLine(line)
+
+	modelCtx.Nodes.MustLockProcess(processID, func(process Process) {
+		process.OutputLineIDs = append(process.OutputLineIDs, line.ID)
+
+		if evicted := len(process.OutputLineIDs) - p.outputCap; evicted > 0 {
+			for _, oldLineID := range process.OutputLineIDs[:evicted] {
+				modelCtx.Nodes.MustDeleteOutputLine(oldLineID)
+			}
+
+			// Reslicing is enough since copies of the process never read past
+			// their length. Append reallocates the slice once its capacity is
+			// used up, which drops the evicted IDs.
+			process.OutputLineIDs = process.OutputLineIDs[evicted:]
+		}
+
+		modelCtx.Nodes.MustStoreProcess(process)
+	})
+
+	modelCtx.Subs.Publish(ProcessOutputAdded, line.ID)
+}
+
+// readyOnMatch wraps a log function to mark the process as ready when a line matches the regexp.
+func (p *ProcessManager) readyOnMatch(
+	ctx context.Context,
+	processID string,
+	cmd **exec.Cmd,
+	re *regexp.Regexp,
+	write func(ownerID, message string, a ...interface{}) string,
+) func(ownerID, message string, a ...interface{}) string {
+	return func(ownerID, message string, a ...interface{}) string {
+		if re.MatchString(message) {
+			p.markReady(ctx, processID, *cmd)
+		}
+
+		return write(ownerID, message, a...)
+	}
+}
+
+// markReady changes the status of a starting process to ready.
+func (p *ProcessManager) markReady(ctx context.Context, processID string, cmd *exec.Cmd) {
+	modelCtx := GetModelContext(ctx)
+	ready := false
+
+	modelCtx.Nodes.MustLockProcess(processID, func(process Process) {
+		if actual, ok := p.commands.Load(processID); !ok || actual.(*exec.Cmd) != cmd {
+			return
+		}
+
+		if process.Status != ProcessStatusStarting {
+			return
+		}
+
+		process.Status = ProcessStatusReady
+		modelCtx.Nodes.MustStoreProcess(process)
+		ready = true
+	})
+
+	if ready {
+		modelCtx.Log.DebugWithOwner(processID, "process is ready")
+		modelCtx.Subs.Publish(ProcessUpserted, processID)
+		modelCtx.Subs.Publish(ProcessGroupUpserted, modelCtx.Nodes.MustLoadProcess(processID).ProcessGroupID)
+	}
+}
+
+// scheduleRestart restarts a process after the delay unless the restart is canceled.
+func (p *ProcessManager) scheduleRestart(ctx context.Context, processID string, delay time.Duration) {
+	p.restartsMu.Lock()
+	restartID := atomic.AddUint64(&p.lastRestartID, 1)
+	p.restarts[processID] = restartID
+	p.restartsMu.Unlock()
+
+	time.AfterFunc(delay, func() {
+		p.restartsMu.Lock()
+		if p.restarts[processID] != restartID {
+			p.restartsMu.Unlock()
+			return
+		}
+		delete(p.restarts, processID)
+		p.restartsMu.Unlock()
+
+		p.restart(ctx, processID)
+	})
+}
+
+// cancelRestart cancels a scheduled restart.
+// It returns whether a restart was scheduled.
+func (p *ProcessManager) cancelRestart(processID string) bool {
+	p.restartsMu.Lock()
+	defer p.restartsMu.Unlock()
+
+	_, ok := p.restarts[processID]
+	delete(p.restarts, processID)
+
+	return ok
+}
+
+func (p *ProcessManager) restart(ctx context.Context, processID string) {
+	modelCtx := GetModelContext(ctx)
+	restart := false
+
+	modelCtx.Nodes.MustLockProcess(processID, func(process Process) {
+		switch process.Status {
+		case ProcessStatusDone:
+			atomic.AddInt64(&p.doneCounter, -1)
+		case ProcessStatusFailed:
+			atomic.AddInt64(&p.failedCounter, -1)
+		default:
+			return
+		}
+
+		process.RestartCount++
+		modelCtx.Nodes.MustStoreProcess(process)
+		restart = true
+	})
+
+	if restart {
+		p.exec(ctx, processID) // will publish metrics
+	}
+}
+
+// SampleUsage measures the CPU and memory usage of running processes
+// periodically until the context is done. An interval of zero or less
+// disables sampling.
+func (p *ProcessManager) SampleUsage(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.sampleUsage(ctx)
+		}
+	}
+}
+
+// sampleUsage measures the CPU and memory usage of the process group of
+// every running process.
+func (p *ProcessManager) sampleUsage(ctx context.Context) {
+	modelCtx := GetModelContext(ctx)
+	usages := map[string]processUsage{}
+	cpuPercent, rss := 0.0, int64(0)
+
+	var groups map[int]processGroupUsage
+
+	p.usageMu.Lock()
+	defer p.usageMu.Unlock()
+
+	p.commands.Range(func(k, v interface{}) bool {
+		processID, cmd := k.(string), v.(*exec.Cmd)
+
+		// Scan /proc only if a process is running.
+		if groups == nil {
+			groups = readProcessGroupUsages()
+		}
+
+		// The process group ID is the PID of the command.
+		group, ok := groups[cmd.Process.Pid]
+		if !ok {
+			return true
+		}
+
+		ticks, bytes := group.CPUTicks, group.RSS
+
+		now := time.Now()
+		usage := processUsage{cmd: cmd, cpuTicks: ticks, sampledAt: now}
+		usages[processID] = usage
+
+		prev, ok := p.usages[processID]
+		if !ok || prev.cmd != cmd || ticks < prev.cpuTicks {
+			return true
+		}
+
+		elapsed := now.Sub(prev.sampledAt).Seconds()
+		percent := float64(ticks-prev.cpuTicks) / clockTicks / elapsed * 100
+		processRSS := int(bytes)
+		updated := false
+
+		modelCtx.Nodes.MustLockProcess(processID, func(process Process) {
+			// The process could have exited or been restarted in the meantime.
+			if actual, ok := p.commands.Load(processID); !ok || actual.(*exec.Cmd) != cmd {
+				return
+			}
+
+			process.CPUPercent = &percent
+			process.RSS = &processRSS
+			modelCtx.Nodes.MustStoreProcess(process)
+			updated = true
+		})
+
+		if updated {
+			cpuPercent += percent
+			rss += bytes
+			modelCtx.Subs.Publish(ProcessUpserted, processID)
+		}
+
+		return true
+	})
+
+	p.usages = usages
+	p.cpuPercent = cpuPercent
+	p.rss = rss
+
+	p.publishMetricsLocked(ctx)
+}
+
 func (p *ProcessManager) publishMetrics(ctx context.Context) {
+	p.usageMu.Lock()
+	defer p.usageMu.Unlock()
+
+	p.publishMetricsLocked(ctx)
+}
+
+// publishMetricsLocked publishes the process metrics.
+// The usage mutex must be held.
+func (p *ProcessManager) publishMetricsLocked(ctx context.Context) {
 	modelCtx := GetModelContext(ctx)
 	system := modelCtx.Nodes.MustLoadSystem(modelCtx.SystemID)
 
@@ -290,6 +882,8 @@ func (p *ProcessManager) publishMetrics(ctx context.Context) {
 		metrics.Running = int(atomic.LoadInt64(&p.runningCounter))
 		metrics.Done = int(atomic.LoadInt64(&p.doneCounter))
 		metrics.Failed = int(atomic.LoadInt64(&p.failedCounter))
+		metrics.CPUPercent = p.cpuPercent
+		metrics.Rss = int(p.rss)
 		modelCtx.Nodes.MustStoreProcessMetrics(metrics)
 	})
 
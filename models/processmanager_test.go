@@ -0,0 +1,69 @@
+// Copyright 2019 Stratumn
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"groundcontrol/pubsub"
+	"groundcontrol/relay"
+)
+
+func TestProcessManager_addOutput(t *testing.T) {
+	modelCtx := &ModelContext{
+		Nodes: &NodeManager{},
+		Subs:  pubsub.New(10),
+	}
+	ctx := WithModelContext(context.Background(), modelCtx)
+
+	processID := relay.EncodeID(NodeTypeProcess, "0")
+	modelCtx.Nodes.MustStoreProcess(Process{ID: processID})
+
+	p := NewProcessManager(3)
+
+	for i := 0; i < 10; i++ {
+		p.addOutput(ctx, processID, OutputStreamStdout, fmt.Sprint(i))
+	}
+
+	var texts []string
+	for _, id := range modelCtx.Nodes.MustLoadProcess(processID).OutputLineIDs {
+		texts = append(texts, modelCtx.Nodes.MustLoadOutputLine(id).Text)
+	}
+
+	assert.Equal(t, []string{"7", "8", "9"}, texts)
+
+	_, err := modelCtx.Nodes.LoadOutputLine(relay.EncodeID(NodeTypeOutputLine, "1"))
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestProcessManager_SampleUsage_disabled(t *testing.T) {
+	done := make(chan struct{})
+
+	go func() {
+		NewProcessManager(1).SampleUsage(context.Background(), 0)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("SampleUsage didn't return")
+	}
+}
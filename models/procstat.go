@@ -0,0 +1,113 @@
+// Copyright 2019 Stratumn
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// clockTicks is the number of clock ticks per second used by /proc.
+// It is almost always 100 on Linux.
+const clockTicks = 100
+
+// procStat contains the fields of /proc/<pid>/stat used to measure resource usage.
+type procStat struct {
+	Pgrp     int
+	CPUTicks uint64
+	RSSPages int64
+}
+
+// parseProcStat parses the content of /proc/<pid>/stat.
+func parseProcStat(data []byte) (procStat, error) {
+	// The command name is in parentheses and may contain spaces.
+	end := bytes.LastIndexByte(data, ')')
+	if end < 0 {
+		return procStat{}, errors.New("malformed stat")
+	}
+
+	// Fields after the command name, starting with the state (field 3).
+	fields := bytes.Fields(data[end+1:])
+	if len(fields) < 22 {
+		return procStat{}, errors.New("malformed stat")
+	}
+
+	pgrp, err := strconv.Atoi(string(fields[2]))
+	if err != nil {
+		return procStat{}, err
+	}
+
+	utime, err := strconv.ParseUint(string(fields[11]), 10, 64)
+	if err != nil {
+		return procStat{}, err
+	}
+
+	stime, err := strconv.ParseUint(string(fields[12]), 10, 64)
+	if err != nil {
+		return procStat{}, err
+	}
+
+	rss, err := strconv.ParseInt(string(fields[21]), 10, 64)
+	if err != nil {
+		return procStat{}, err
+	}
+
+	return procStat{
+		Pgrp:     pgrp,
+		CPUTicks: utime + stime,
+		RSSPages: rss,
+	}, nil
+}
+
+// processGroupUsage is the resource usage of all the processes in a group.
+type processGroupUsage struct {
+	CPUTicks uint64
+	RSS      int64
+}
+
+// readProcessGroupUsages scans /proc once and returns the CPU ticks and the
+// resident set size in bytes of every process group indexed by ID. It is
+// empty if /proc isn't available.
+func readProcessGroupUsages() map[int]processGroupUsage {
+	usages := map[int]processGroupUsage{}
+
+	// The pattern is valid so there can't be an error.
+	paths, _ := filepath.Glob("/proc/[0-9]*/stat")
+	pageSize := int64(os.Getpagesize())
+
+	for _, path := range paths {
+		// The process could have exited in the meantime.
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		stat, err := parseProcStat(data)
+		if err != nil {
+			continue
+		}
+
+		usage := usages[stat.Pgrp]
+		usage.CPUTicks += stat.CPUTicks
+		usage.RSS += stat.RSSPages * pageSize
+		usages[stat.Pgrp] = usage
+	}
+
+	return usages
+}
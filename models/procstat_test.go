@@ -0,0 +1,55 @@
+// Copyright 2019 Stratumn
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseProcStat(t *testing.T) {
+	data := []byte("4242 (node (dev) server) S 1 4240 4240 0 -1 4194304 1000 0 0 0 150 50 0 0 20 0 11 0 123456 1000000000 2560 18446744073709551615\n")
+
+	stat, err := parseProcStat(data)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 4240, stat.Pgrp)
+	assert.Equal(t, uint64(200), stat.CPUTicks)
+	assert.Equal(t, int64(2560), stat.RSSPages)
+}
+
+func TestParseProcStat_malformed(t *testing.T) {
+	_, err := parseProcStat([]byte("4242 node S 1"))
+	assert.Error(t, err)
+
+	_, err = parseProcStat([]byte("4242 (node) S 1 4240"))
+	assert.Error(t, err)
+}
+
+func TestReadProcessGroupUsages(t *testing.T) {
+	if _, err := os.Stat("/proc/self/stat"); err != nil {
+		t.Skip("/proc isn't available")
+	}
+
+	usages := readProcessGroupUsages()
+
+	usage, ok := usages[syscall.Getpgrp()]
+	if assert.True(t, ok) {
+		assert.True(t, usage.RSS > 0)
+	}
+}
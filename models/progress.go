@@ -0,0 +1,199 @@
+// Copyright 2019 Stratumn
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProgressInterval is the minimum duration between two progress updates of a
+// job being published.
+const ProgressInterval = 250 * time.Millisecond
+
+// progressRegexp matches Git progress lines such as
+// "Receiving objects:  45% (450/1000)".
+var progressRegexp = regexp.MustCompile(`^(?:remote: )?(.+?):\s+(\d+)% \(\d+/\d+\)`)
+
+// progressStages maps the stages of Git progress output to the range of the
+// job's progress they cover. Unknown stages cover the whole range.
+var progressStages = map[string][2]float64{
+	"Enumerating objects": {0, 0.05},
+	"Counting objects":    {0.05, 0.15},
+	"Compressing objects": {0.15, 0.3},
+	"Receiving objects":   {0.3, 0.9},
+	"Resolving deltas":    {0.9, 1},
+}
+
+// ProgressReporter reports the progress of a job.
+//
+// It implements io.Writer so it can be given to Git as the sideband progress
+// writer. Updates are published at most once every ProgressInterval.
+type ProgressReporter struct {
+	modelCtx *ModelContext
+	jobID    string
+
+	mu        sync.Mutex
+	progress  float64
+	message   string
+	published time.Time
+	timer     *time.Timer
+	closed    bool
+	buf       []byte
+}
+
+func newProgressReporter(modelCtx *ModelContext, jobID string) *ProgressReporter {
+	return &ProgressReporter{
+		modelCtx: modelCtx,
+		jobID:    jobID,
+	}
+}
+
+// Report sets the progress, between zero and one, and a message describing the
+// current step.
+func (r *ProgressReporter) Report(progress float64, message string) {
+	if progress < 0 {
+		progress = 0
+	} else if progress > 1 {
+		progress = 1
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return
+	}
+
+	r.progress = progress
+	r.message = message
+
+	wait := ProgressInterval - time.Since(r.published)
+	if wait <= 0 {
+		r.publishLocked()
+		return
+	}
+
+	if r.timer == nil {
+		r.timer = time.AfterFunc(wait, func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+
+			r.timer = nil
+
+			if !r.closed {
+				r.publishLocked()
+			}
+		})
+	}
+}
+
+// Write parses Git progress output. Lines are separated by carriage returns
+// or line feeds.
+func (r *ProgressReporter) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	r.buf = append(r.buf, p...)
+
+	var lines []string
+
+	for {
+		i := bytes.IndexAny(r.buf, "\r\n")
+		if i < 0 {
+			break
+		}
+
+		if line := strings.TrimSpace(string(r.buf[:i])); line != "" {
+			lines = append(lines, line)
+		}
+
+		r.buf = r.buf[i+1:]
+	}
+
+	r.mu.Unlock()
+
+	for _, line := range lines {
+		if progress, ok := parseProgress(line); ok {
+			r.Report(progress, line)
+		}
+	}
+
+	return len(p), nil
+}
+
+// values returns the last reported progress and message.
+func (r *ProgressReporter) values() (float64, *string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.message == "" {
+		return r.progress, nil
+	}
+
+	message := r.message
+
+	return r.progress, &message
+}
+
+// close stops publishing updates.
+func (r *ProgressReporter) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closed = true
+
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+}
+
+func (r *ProgressReporter) publishLocked() {
+	r.published = time.Now()
+	message := r.message
+
+	r.modelCtx.Nodes.MustLockJob(r.jobID, func(job Job) {
+		job.Progress = r.progress
+		job.ProgressMessage = &message
+		job.UpdatedAt = DateTime(r.published)
+		r.modelCtx.Nodes.MustStoreJob(job)
+	})
+
+	r.modelCtx.Subs.Publish(JobUpserted, r.jobID)
+}
+
+// parseProgress parses a line of Git progress output and returns the overall
+// progress it represents.
+func parseProgress(line string) (float64, bool) {
+	matches := progressRegexp.FindStringSubmatch(line)
+	if matches == nil {
+		return 0, false
+	}
+
+	percent, err := strconv.Atoi(matches[2])
+	if err != nil {
+		return 0, false
+	}
+
+	stage, ok := progressStages[matches[1]]
+	if !ok {
+		stage = [2]float64{0, 1}
+	}
+
+	return stage[0] + (stage[1]-stage[0])*float64(percent)/100, true
+}
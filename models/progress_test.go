@@ -0,0 +1,40 @@
+// Copyright 2019 Stratumn
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseProgress(t *testing.T) {
+	progress, ok := parseProgress("Counting objects:  50% (5/10)")
+	assert.True(t, ok)
+	assert.InDelta(t, 0.1, progress, 1e-9)
+
+	progress, ok = parseProgress("remote: Compressing objects: 100% (10/10), done.")
+	assert.True(t, ok)
+	assert.InDelta(t, 0.3, progress, 1e-9)
+
+	progress, ok = parseProgress("Writing objects:  25% (1/4)")
+	assert.True(t, ok)
+	assert.InDelta(t, 0.25, progress, 1e-9)
+}
+
+func TestParseProgress_noPercentage(t *testing.T) {
+	_, ok := parseProgress("Total 10 (delta 2), reused 0 (delta 0)")
+	assert.False(t, ok)
+}
@@ -17,23 +17,60 @@ package models
 import (
 	"context"
 	"os"
+	"strings"
 )
 
+var pullStrategies = map[string]PullStrategy{
+	"":        PullStrategyFfOnly,
+	"ff-only": PullStrategyFfOnly,
+	"rebase":  PullStrategyRebase,
+	"merge":   PullStrategyMerge,
+}
+
+// ParsePullStrategy returns the pull strategy with the given name,
+// which can be "ff-only", "rebase" or "merge".
+func ParsePullStrategy(name string) (PullStrategy, error) {
+	strategy, ok := pullStrategies[strings.ToLower(name)]
+	if !ok {
+		return "", ErrPullStrategy
+	}
+
+	return strategy, nil
+}
+
 // Project represents a project in the app.
 type Project struct {
-	ID               string   `json:"id"`
-	Slug             string   `json:"slug"`
-	Repository       string   `json:"repository"`
-	Branch           string   `json:"branch"`
-	Description      *string  `json:"description"`
-	WorkspaceID      string   `json:"workspaceId"`
-	CommitIDs        []string `json:"commitIds"`
-	Tasks            []Task   `json:"projects"`
-	IsLoadingCommits bool     `json:"isLoadingCommits"`
-	IsCloning        bool     `json:"isCloning"`
-	IsPulling        bool     `json:"isPulling"`
-	IsBehind         bool     `json:"isBehind"`
-	IsAhead          bool     `json:"isAhead"`
+	ID                string       `json:"id"`
+	Slug              string       `json:"slug"`
+	Repository        string       `json:"repository"`
+	Branch            string       `json:"branch"`
+	Description       *string      `json:"description"`
+	Depth             int          `json:"depth"`
+	RecurseSubmodules bool         `json:"recurseSubmodules"`
+	PullStrategy      PullStrategy `json:"pullStrategy"`
+	WorkspaceID       string       `json:"workspaceId"`
+	CommitIDs         []string     `json:"commitIds"`
+	Tasks             []Task       `json:"projects"`
+	IsLoadingCommits  bool         `json:"isLoadingCommits"`
+	IsCloning         bool         `json:"isCloning"`
+	IsPulling         bool         `json:"isPulling"`
+	IsPushing         bool         `json:"isPushing"`
+	IsCheckingOut     bool         `json:"isCheckingOut"`
+	// CurrentBranch is the branch checked out in the worktree, which can differ
+	// from the configured branch. It is nil if unknown or if the head is detached.
+	CurrentBranch     *string  `json:"currentBranch"`
+	IsBehind          bool     `json:"isBehind"`
+	IsAhead           bool     `json:"isAhead"`
+	BehindCount       int      `json:"behindCount"`
+	AheadCount        int      `json:"aheadCount"`
+	IncomingCommitIDs []string `json:"incomingCommitIds"`
+	OutgoingCommitIDs []string `json:"outgoingCommitIds"`
+	IsDirty           bool     `json:"isDirty"`
+	ModifiedFiles     []string `json:"modifiedFiles"`
+	UntrackedFiles    []string `json:"untrackedFiles"`
+	StagedFiles       []string `json:"stagedFiles"`
+	// ConflictingFiles are the files that prevented the last pull from completing.
+	ConflictingFiles []string `json:"conflictingFiles"`
 }
 
 // IsNode tells gqlgen that it implements Node.
@@ -74,3 +111,39 @@ func (p Project) Commits(
 		last,
 	)
 }
+
+// IncomingCommits returns paginated commits of the remote branch missing from the local branch.
+func (p Project) IncomingCommits(
+	ctx context.Context,
+	after *string,
+	before *string,
+	first *int,
+	last *int,
+) (CommitConnection, error) {
+	return PaginateCommitIDSliceContext(
+		ctx,
+		p.IncomingCommitIDs,
+		after,
+		before,
+		first,
+		last,
+	)
+}
+
+// OutgoingCommits returns paginated commits of the local branch missing from the remote branch.
+func (p Project) OutgoingCommits(
+	ctx context.Context,
+	after *string,
+	before *string,
+	first *int,
+	last *int,
+) (CommitConnection, error) {
+	return PaginateCommitIDSliceContext(
+		ctx,
+		p.OutgoingCommitIDs,
+		after,
+		before,
+		first,
+		last,
+	)
+}
@@ -0,0 +1,82 @@
+// Copyright 2019 Stratumn
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+// Readiness probe defaults.
+const (
+	// DefaultReadinessProbeInterval is how long to wait between checks if not specified.
+	DefaultReadinessProbeInterval = 500 * time.Millisecond
+
+	// DefaultReadinessProbeTimeout is how long to wait for a process to be ready if not specified.
+	DefaultReadinessProbeTimeout = time.Minute
+)
+
+// ReadinessProbe tells when a background process is ready.
+type ReadinessProbe struct {
+	TCP      *string  `json:"tcp"`
+	HTTP     *string  `json:"http"`
+	Log      *string  `json:"log"`
+	Interval Duration `json:"interval"`
+	Timeout  Duration `json:"timeout"`
+}
+
+// LogRegexp returns the compiled log regular expression if there is one.
+func (r ReadinessProbe) LogRegexp() (*regexp.Regexp, error) {
+	if r.Log == nil {
+		return nil, nil
+	}
+
+	return regexp.Compile(*r.Log)
+}
+
+// Check connects to the TCP address or HTTP URL and returns whether it succeeded.
+// It always returns false for log probes.
+func (r ReadinessProbe) Check(ctx context.Context) bool {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.Interval))
+	defer cancel()
+
+	switch {
+	case r.TCP != nil:
+		dialer := net.Dialer{}
+		conn, err := dialer.DialContext(ctx, "tcp", *r.TCP)
+		if err != nil {
+			return false
+		}
+		conn.Close()
+		return true
+
+	case r.HTTP != nil:
+		req, err := http.NewRequest(http.MethodGet, *r.HTTP, nil)
+		if err != nil {
+			return false
+		}
+		res, err := http.DefaultClient.Do(req.WithContext(ctx))
+		if err != nil {
+			return false
+		}
+		res.Body.Close()
+		return res.StatusCode < 400
+	}
+
+	return false
+}
@@ -0,0 +1,61 @@
+// Copyright 2019 Stratumn
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import "time"
+
+// RetryPolicy tells whether and when a failed job is retried.
+type RetryPolicy struct {
+	// MaxAttempts is how many times the job can run, including the first
+	// attempt.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry. It doubles after each
+	// attempt.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay if it isn't zero.
+	MaxDelay time.Duration
+	// IsRetryable tells whether a job that failed with the given error should
+	// be retried. If nil, all errors are retried.
+	IsRetryable func(error) bool
+}
+
+// shouldRetry tells whether a job should be retried after the given attempt
+// failed. It can be called on a nil policy.
+func (p *RetryPolicy) shouldRetry(attempt int, err error) bool {
+	if p == nil || attempt >= p.MaxAttempts {
+		return false
+	}
+
+	return p.IsRetryable == nil || p.IsRetryable(err)
+}
+
+// delay returns how long to wait before retrying after the given attempt.
+func (p *RetryPolicy) delay(attempt int) time.Duration {
+	delay := p.BaseDelay
+
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+
+		if p.MaxDelay > 0 && delay >= p.MaxDelay {
+			break
+		}
+	}
+
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	return delay
+}
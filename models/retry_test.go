@@ -0,0 +1,49 @@
+// Copyright 2019 Stratumn
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicy_delay(t *testing.T) {
+	policy := &RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+
+	assert.Equal(t, time.Second, policy.delay(1))
+	assert.Equal(t, 2*time.Second, policy.delay(2))
+	assert.Equal(t, 4*time.Second, policy.delay(3))
+	assert.Equal(t, 5*time.Second, policy.delay(4))
+	assert.Equal(t, 5*time.Second, policy.delay(100))
+}
+
+func TestRetryPolicy_shouldRetry(t *testing.T) {
+	errRetryable := errors.New("retryable")
+	policy := &RetryPolicy{
+		MaxAttempts: 3,
+		IsRetryable: func(err error) bool { return err == errRetryable },
+	}
+
+	assert.True(t, policy.shouldRetry(1, errRetryable))
+	assert.True(t, policy.shouldRetry(2, errRetryable))
+	assert.False(t, policy.shouldRetry(3, errRetryable))
+	assert.False(t, policy.shouldRetry(1, errors.New("fatal")))
+
+	var noPolicy *RetryPolicy
+	assert.False(t, noPolicy.shouldRetry(1, errRetryable))
+}
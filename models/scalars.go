@@ -54,6 +54,23 @@ func (d DateTime) MarshalGQL(w io.Writer) {
 	w.Write([]byte(strconv.Quote(time.Time(d).Format(DateFormat))))
 }
 
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (d *DateTime) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var t time.Time
+	if err := unmarshal(&t); err != nil {
+		return err
+	}
+
+	*d = DateTime(t)
+
+	return nil
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (d DateTime) MarshalYAML() (interface{}, error) {
+	return time.Time(d), nil
+}
+
 // Hash holds a Git hash.
 // TODO: change to bytes.
 type Hash string
@@ -74,3 +91,28 @@ func (h *Hash) UnmarshalGQL(v interface{}) error {
 func (h Hash) MarshalGQL(w io.Writer) {
 	w.Write([]byte(strconv.Quote(string(h))))
 }
+
+// Duration holds a duration.
+type Duration time.Duration
+
+// UnmarshalGQL implements the graphql.Marshaler interface.
+func (d *Duration) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("Duration must be string")
+	}
+
+	duration, err := time.ParseDuration(str)
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(duration)
+
+	return nil
+}
+
+// MarshalGQL implements the graphql.Marshaler interface.
+func (d Duration) MarshalGQL(w io.Writer) {
+	w.Write([]byte(strconv.Quote(time.Duration(d).String())))
+}
@@ -0,0 +1,80 @@
+// Copyright 2019 Stratumn
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// SnapshotExt is the extension of workspace snapshot lockfiles.
+// It must differ from the extension of workspace config files.
+const SnapshotExt = ".lock"
+
+// WorkspaceSnapshot contains all the data in a YAML workspace snapshot lockfile.
+type WorkspaceSnapshot struct {
+	Filename  string            `json:"filename" yaml:"-"`
+	Workspace string            `json:"workspace"`
+	Date      DateTime          `json:"date"`
+	Projects  []ProjectSnapshot `json:"projects"`
+}
+
+// ProjectSnapshot contains the state of a project in a workspace snapshot.
+// Branch is nil if the head was detached.
+type ProjectSnapshot struct {
+	Slug       string  `json:"slug"`
+	Repository string  `json:"repository"`
+	Branch     *string `json:"branch"`
+	Hash       Hash    `json:"hash"`
+	IsDirty    bool    `json:"isDirty" yaml:"isDirty"`
+}
+
+// Save saves the snapshot to disk, overwriting the file if it exists.
+func (s WorkspaceSnapshot) Save() error {
+	bytes, err := yaml.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(s.Filename, bytes, 0644)
+}
+
+// LoadWorkspaceSnapshotYAML loads a workspace snapshot from a YAML file.
+func LoadWorkspaceSnapshotYAML(filename string) (WorkspaceSnapshot, error) {
+	snapshot := WorkspaceSnapshot{
+		Filename: filename,
+	}
+
+	bytes, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return snapshot, err
+	}
+
+	err = yaml.UnmarshalStrict(bytes, &snapshot)
+
+	return snapshot, err
+}
+
+// SnapshotFilename returns the name of the snapshot lockfile of the workspace,
+// which is next to its config file.
+func (w Workspace) SnapshotFilename() string {
+	dir, base := filepath.Split(w.ConfigFilename)
+	name := strings.TrimSuffix(base, filepath.Ext(base))
+
+	return filepath.Join(dir, name+"."+w.Slug+SnapshotExt)
+}
@@ -0,0 +1,69 @@
+// Copyright 2019 Stratumn
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkspace_SnapshotFilename(t *testing.T) {
+	workspace := Workspace{
+		Slug:           "app",
+		ConfigFilename: filepath.Join("sources", "workspaces.yml"),
+	}
+
+	assert.Equal(t, filepath.Join("sources", "workspaces.app.lock"), workspace.SnapshotFilename())
+}
+
+func TestWorkspaceSnapshot_Save(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshot")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	branch := "master"
+	date, _ := time.Parse(time.RFC3339, "2019-02-01T10:00:00Z")
+
+	snapshot := WorkspaceSnapshot{
+		Filename:  filepath.Join(dir, "workspaces.app.lock"),
+		Workspace: "app",
+		Date:      DateTime(date),
+		Projects: []ProjectSnapshot{{
+			Slug:       "api",
+			Repository: "git@github.com:stratumn/api.git",
+			Branch:     &branch,
+			Hash:       "0123456789abcdef0123456789abcdef01234567",
+		}, {
+			Slug:       "ui",
+			Repository: "git@github.com:stratumn/ui.git",
+			Hash:       "89abcdef0123456789abcdef0123456789abcdef",
+			IsDirty:    true,
+		}},
+	}
+
+	assert.NoError(t, snapshot.Save())
+
+	loaded, err := LoadWorkspaceSnapshotYAML(snapshot.Filename)
+	assert.NoError(t, err)
+	assert.True(t, time.Time(snapshot.Date).Equal(time.Time(loaded.Date)))
+
+	loaded.Date = snapshot.Date
+	assert.Equal(t, snapshot, loaded)
+}
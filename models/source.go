@@ -38,19 +38,29 @@ type Source interface {
 	) (WorkspaceConnection, error)
 }
 
-// MustLoadSource loads a Source or panics on failure.
-func (n *NodeManager) MustLoadSource(id string) Source {
+// LoadSource loads a Source.
+func (n *NodeManager) LoadSource(id string) (Source, error) {
 	identifiers, err := relay.DecodeID(id)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	switch identifiers[0] {
 	case NodeTypeDirectorySource:
-		return n.MustLoadDirectorySource(id)
+		return n.LoadDirectorySource(id)
 	case NodeTypeGitSource:
-		return n.MustLoadGitSource(id)
+		return n.LoadGitSource(id)
+	}
+
+	return nil, ErrType
+}
+
+// MustLoadSource loads a Source or panics on failure.
+func (n *NodeManager) MustLoadSource(id string) Source {
+	node, err := n.LoadSource(id)
+	if err != nil {
+		panic(err)
 	}
 
-	panic(ErrType)
+	return node
 }
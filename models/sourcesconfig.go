@@ -30,6 +30,7 @@ type SourcesConfig struct {
 	Filename         string                  `json:"-" yaml:"-"`
 	DirectorySources []DirectorySourceConfig `json:"directorySources" yaml:"directory-sources"`
 	GitSources       []GitSourceConfig       `json:"gitSources" yaml:"git-sources"`
+	Credentials      []CredentialConfig      `json:"credentials" yaml:"credentials,omitempty"`
 }
 
 // DirectorySourceConfig contains all the data in a YAML directory source config file.
@@ -18,10 +18,12 @@ import "context"
 
 // Step represents a task step in the app.
 type Step struct {
-	ID         string   `json:"id"`
-	ProjectIDs []string `json:"projectIds"`
-	CommandIDs []string `json:"commandIds"`
-	TaskID     string   `json:"taskId"`
+	ID          string   `json:"id"`
+	ProjectIDs  []string `json:"projectIds"`
+	CommandIDs  []string `json:"commandIds"`
+	TaskID      string   `json:"taskId"`
+	Parallel    bool     `json:"parallel"`
+	MaxParallel int      `json:"maxParallel"`
 }
 
 // IsNode tells gqlgen that it implements Node.
@@ -46,7 +48,7 @@ func (s Step) Commands(
 	first *int,
 	last *int,
 ) (CommandConnection, error) {
-	return PaginateCommandIDSliceContext(ctx, s.ProjectIDs, after, before, first, last)
+	return PaginateCommandIDSliceContext(ctx, s.CommandIDs, after, before, first, last)
 }
 
 // Task returns the step's taks.
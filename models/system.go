@@ -18,6 +18,7 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"time"
 )
 
 // System contains information about the running app.
@@ -42,13 +43,33 @@ func (s System) Jobs(
 	first *int,
 	last *int,
 	status []JobStatus,
+	name *string,
+	ownerID *string,
+	createdAfter *DateTime,
+	createdBefore *DateTime,
 ) (JobConnection, error) {
 	var slice []Job
 
 	nodes := GetModelContext(ctx).Nodes
 
 	for _, nodeID := range s.JobIDs {
-		node := nodes.MustLoadJob(nodeID)
+		node, err := nodes.LoadJob(nodeID)
+		if err != nil {
+			// The job was deleted after the system was loaded.
+			continue
+		}
+
+		switch {
+		case name != nil && node.Name != *name:
+			continue
+		case ownerID != nil && node.OwnerID != *ownerID:
+			continue
+		case createdAfter != nil && !time.Time(node.CreatedAt).After(time.Time(*createdAfter)):
+			continue
+		case createdBefore != nil && !time.Time(node.CreatedAt).Before(time.Time(*createdBefore)):
+			continue
+		}
+
 		match := len(status) == 0
 
 		for _, v := range status {
@@ -77,6 +98,11 @@ func (s System) JobMetrics(ctx context.Context) JobMetrics {
 	return GetModelContext(ctx).Nodes.MustLoadJobMetrics(s.JobMetricsID)
 }
 
+// JobConcurrency returns the concurrency limits of the job pools.
+func (s System) JobConcurrency(ctx context.Context) []JobConcurrency {
+	return GetModelContext(ctx).Jobs.Concurrency()
+}
+
 // ProcessGroups returns paginated process groups.
 func (s System) ProcessGroups(
 	ctx context.Context,
@@ -14,21 +14,52 @@
 
 package models
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"strings"
+)
 
 // Task represents a workspace task in the app.
 type Task struct {
-	ID          string   `json:"id"`
-	Name        string   `json:"name"`
-	VariableIDs []string `json:"variableIds"`
-	StepIDs     []string `json:"stepIds"`
-	WorkspaceID string   `json:"workspace"`
-	IsRunning   bool     `json:"isRunning"`
+	ID            string   `json:"id"`
+	Name          string   `json:"name"`
+	DependencyIDs []string `json:"dependencyIds"`
+	VariableIDs   []string `json:"variableIds"`
+	StepIDs       []string `json:"stepIds"`
+	WorkspaceID   string   `json:"workspace"`
+	IsRunning     bool     `json:"isRunning"`
 }
 
 // IsNode tells gqlgen that it implements Node.
 func (Task) IsNode() {}
 
+// Dependencies returns the tasks that must run before the task.
+func (t Task) Dependencies(
+	ctx context.Context,
+	after *string,
+	before *string,
+	first *int,
+	last *int,
+) (TaskConnection, error) {
+	return PaginateTaskIDSliceContext(ctx, t.DependencyIDs, after, before, first, last)
+}
+
+// AllDependencyIDs returns the IDs of the direct and indirect dependencies of the task
+// in the order they must run.
+func (t Task) AllDependencyIDs(ctx context.Context) ([]string, error) {
+	nodes := GetModelContext(ctx).Nodes
+
+	return sortDependencies(t.ID, func(id string) ([]string, error) {
+		task, err := nodes.LoadTask(id)
+		if err != nil {
+			return nil, err
+		}
+
+		return task.DependencyIDs, nil
+	})
+}
+
 // Variables returns the task's variables.
 func (t Task) Variables(
 	ctx context.Context,
@@ -40,6 +71,34 @@ func (t Task) Variables(
 	return PaginateVariableIDSliceContext(ctx, t.VariableIDs, after, before, first, last)
 }
 
+// Env returns the environment to run the task with. The variables of the task
+// that aren't in the base environment are set to the key of the same name, or
+// to their default value. Variables without either are left unset.
+func (t Task) Env(ctx context.Context, base []string) []string {
+	modelCtx := GetModelContext(ctx)
+	env := append([]string(nil), base...)
+
+	set := map[string]bool{}
+	for _, entry := range base {
+		set[strings.SplitN(entry, "=", 2)[0]] = true
+	}
+
+	for _, variableID := range t.VariableIDs {
+		variable := modelCtx.Nodes.MustLoadVariable(variableID)
+		if set[variable.Name] {
+			continue
+		}
+
+		if value, err := modelCtx.Keys.lookup(variable.Name); err == nil {
+			env = append(env, fmt.Sprintf("%s=%s", variable.Name, value))
+		} else if variable.Default != nil {
+			env = append(env, fmt.Sprintf("%s=%s", variable.Name, *variable.Default))
+		}
+	}
+
+	return env
+}
+
 // Steps returns the task's steps.
 func (t Task) Steps(
 	ctx context.Context,
@@ -55,3 +114,62 @@ func (t Task) Steps(
 func (t Task) Workspace(ctx context.Context) Workspace {
 	return GetModelContext(ctx).Nodes.MustLoadWorkspace(t.WorkspaceID)
 }
+
+// sortDependencies does a topological sort of the dependencies of a node.
+// The node itself is not included in the result.
+// It returns a CycleError if the dependencies form a cycle.
+func sortDependencies(id string, getDependencies func(string) ([]string, error)) ([]string, error) {
+	var (
+		sorted []string
+		path   []string
+		visit  func(string) error
+	)
+
+	const (
+		visiting = iota + 1
+		visited
+	)
+
+	states := map[string]int{}
+
+	visit = func(id string) error {
+		switch states[id] {
+		case visiting:
+			// The node is on the path since it is being visited.
+			i := len(path) - 1
+			for path[i] != id {
+				i--
+			}
+
+			return CycleError{Path: append(append([]string{}, path[i:]...), id)}
+		case visited:
+			return nil
+		}
+
+		states[id] = visiting
+		path = append(path, id)
+
+		dependencies, err := getDependencies(id)
+		if err != nil {
+			return err
+		}
+
+		for _, dependency := range dependencies {
+			if err := visit(dependency); err != nil {
+				return err
+			}
+		}
+
+		states[id] = visited
+		path = path[:len(path)-1]
+		sorted = append(sorted, id)
+
+		return nil
+	}
+
+	if err := visit(id); err != nil {
+		return nil, err
+	}
+
+	return sorted[:len(sorted)-1], nil
+}
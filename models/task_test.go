@@ -0,0 +1,117 @@
+// Copyright 2019 Stratumn
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"groundcontrol/relay"
+)
+
+func TestSortDependencies(t *testing.T) {
+	type args struct {
+		id           string
+		dependencies map[string][]string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []string
+		wantErr error
+	}{{
+		"no dependencies",
+		args{"a", map[string][]string{"a": nil}},
+		[]string{},
+		nil,
+	}, {
+		"chain",
+		args{"a", map[string][]string{"a": {"b"}, "b": {"c"}, "c": nil}},
+		[]string{"c", "b"},
+		nil,
+	}, {
+		"diamond",
+		args{"a", map[string][]string{"a": {"b", "c"}, "b": {"d"}, "c": {"d"}, "d": nil}},
+		[]string{"d", "b", "c"},
+		nil,
+	}, {
+		"cycle",
+		args{"a", map[string][]string{"a": {"b"}, "b": {"a"}}},
+		nil,
+		CycleError{Path: []string{"a", "b", "a"}},
+	}, {
+		"indirect cycle",
+		args{"a", map[string][]string{"a": {"b"}, "b": {"c"}, "c": {"b"}}},
+		nil,
+		CycleError{Path: []string{"b", "c", "b"}},
+	}, {
+		"self",
+		args{"a", map[string][]string{"a": {"a"}}},
+		nil,
+		CycleError{Path: []string{"a", "a"}},
+	}, {
+		"not found",
+		args{"a", map[string][]string{"a": {"b"}}},
+		nil,
+		ErrNotFound,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sortDependencies(tt.args.id, func(id string) ([]string, error) {
+				dependencies, ok := tt.args.dependencies[id]
+				if !ok {
+					return nil, ErrNotFound
+				}
+				return dependencies, nil
+			})
+			if !reflect.DeepEqual(err, tt.wantErr) {
+				assert.Equal(t, tt.wantErr, err)
+				return
+			}
+			if tt.wantErr == nil && !reflect.DeepEqual(got, tt.want) {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestTask_Env(t *testing.T) {
+	modelCtx := &ModelContext{
+		Nodes: &NodeManager{},
+		Keys:  &KeysConfig{Keys: map[string]string{"TOKEN": "saved", "PORT": "3001"}},
+	}
+	ctx := WithModelContext(context.Background(), modelCtx)
+
+	value := "default"
+	variables := []Variable{
+		{ID: relay.EncodeID(NodeTypeVariable, "0"), Name: "PORT", Default: &value},
+		{ID: relay.EncodeID(NodeTypeVariable, "1"), Name: "TOKEN", Default: &value},
+		{ID: relay.EncodeID(NodeTypeVariable, "2"), Name: "MODE", Default: &value},
+		{ID: relay.EncodeID(NodeTypeVariable, "3"), Name: "UNSET"},
+	}
+
+	task := Task{}
+	for _, variable := range variables {
+		modelCtx.Nodes.MustStoreVariable(variable)
+		task.VariableIDs = append(task.VariableIDs, variable.ID)
+	}
+
+	env := task.Env(ctx, []string{"PATH=/bin", "PORT=3000"})
+
+	assert.Equal(t, []string{"PATH=/bin", "PORT=3000", "TOKEN=saved", "MODE=default"}, env)
+}
@@ -0,0 +1,128 @@
+// Copyright 2019 Stratumn
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/kr/pty"
+)
+
+// TerminalChannelSize is the size of the channel of a terminal subscriber.
+// If a channel is full new output will be dropped for that subscriber.
+const TerminalChannelSize = 256
+
+// Terminal is the pseudo-terminal of a running process.
+type Terminal struct {
+	file *os.File
+
+	mu          sync.Mutex
+	subscribers map[chan []byte]struct{}
+	closed      bool
+}
+
+func newTerminal(file *os.File) *Terminal {
+	return &Terminal{
+		file:        file,
+		subscribers: map[chan []byte]struct{}{},
+	}
+}
+
+// Write writes input to the terminal.
+func (t *Terminal) Write(p []byte) (int, error) {
+	return t.file.Write(p)
+}
+
+// Resize changes the size of the terminal.
+func (t *Terminal) Resize(rows, cols uint16) error {
+	return pty.Setsize(t.file, &pty.Winsize{Rows: rows, Cols: cols})
+}
+
+// Subscribe returns a channel receiving the output of the terminal until the
+// context is done or the process exits.
+func (t *Terminal) Subscribe(ctx context.Context) <-chan []byte {
+	ch := make(chan []byte, TerminalChannelSize)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		close(ch)
+		return ch
+	}
+
+	t.subscribers[ch] = struct{}{}
+
+	go func() {
+		<-ctx.Done()
+
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		if _, ok := t.subscribers[ch]; ok {
+			delete(t.subscribers, ch)
+			close(ch)
+		}
+	}()
+
+	return ch
+}
+
+// copyOutput copies the output of the terminal to the writer and the
+// subscribers until the terminal is closed.
+func (t *Terminal) copyOutput(w io.Writer) {
+	buf := make([]byte, 4096)
+
+	for {
+		n, err := t.file.Read(buf)
+		if n > 0 {
+			w.Write(buf[:n])
+			t.broadcast(append([]byte(nil), buf[:n]...))
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (t *Terminal) broadcast(p []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for ch := range t.subscribers {
+		select {
+		case ch <- p:
+		default:
+		}
+	}
+}
+
+// close closes the terminal and the channels of the subscribers.
+func (t *Terminal) close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.closed = true
+
+	for ch := range t.subscribers {
+		delete(t.subscribers, ch)
+		close(ch)
+	}
+
+	return t.file.Close()
+}
@@ -25,6 +25,10 @@ type Workspace struct {
 	TaskIDs     []string `json:"taskIds"`
 	Description string   `json:"description"`
 	Notes       *string  `json:"notes"`
+	// Credentials are the Git credentials of the workspace's projects.
+	Credentials []CredentialConfig `json:"-"`
+	// ConfigFilename is the file the workspace was loaded from.
+	ConfigFilename string `json:"-"`
 }
 
 // IsNode tells gqlgen that it implements Node.
@@ -94,6 +98,20 @@ func (w Workspace) IsPulling(ctx context.Context) bool {
 	return false
 }
 
+// IsPushing returns true if any of the projects is pushing.
+func (w Workspace) IsPushing(ctx context.Context) bool {
+	nodes := GetModelContext(ctx).Nodes
+
+	for _, id := range w.ProjectIDs {
+		node := nodes.MustLoadProject(id)
+		if node.IsPushing {
+			return true
+		}
+	}
+
+	return false
+}
+
 // IsBehind returns true if any of the projects is behind origin.
 func (w Workspace) IsBehind(ctx context.Context) bool {
 	nodes := GetModelContext(ctx).Nodes
@@ -121,3 +139,17 @@ func (w Workspace) IsAhead(ctx context.Context) bool {
 
 	return false
 }
+
+// IsDirty returns true if any of the projects is dirty.
+func (w Workspace) IsDirty(ctx context.Context) bool {
+	nodes := GetModelContext(ctx).Nodes
+
+	for _, id := range w.ProjectIDs {
+		node := nodes.MustLoadProject(id)
+		if node.IsDirty {
+			return true
+		}
+	}
+
+	return false
+}
@@ -17,6 +17,9 @@ package models
 import (
 	"fmt"
 	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
 
 	yaml "gopkg.in/yaml.v2"
 
@@ -38,6 +41,8 @@ type WorkspaceConfig struct {
 	Notes       *string         `json:"notes"`
 	Projects    []ProjectConfig `json:"projects" yaml:",flow"`
 	Tasks       []TaskConfig    `json:"tasks"`
+	// Credentials are used for the projects before the ones of the sources config.
+	Credentials []CredentialConfig `json:"credentials"`
 }
 
 // ProjectConfig contains all the data in a YAML project config file.
@@ -46,11 +51,25 @@ type ProjectConfig struct {
 	Repository  string  `json:"repository"`
 	Branch      string  `json:"branch"`
 	Description *string `json:"description"`
+	// Depth limits the history fetched to the given number of commits.
+	// Zero fetches the whole history.
+	Depth int `json:"depth"`
+	// Submodules can be set to "recursive" to clone and update submodules.
+	Submodules string `json:"submodules"`
+	// SparsePaths isn't supported because go-git can't do sparse checkouts.
+	// It is only read to reject configs that set it.
+	SparsePaths []string `json:"sparsePaths" yaml:"sparsePaths"`
+	// PullStrategy can be "ff-only", "rebase" or "merge".
+	PullStrategy string `json:"pullStrategy" yaml:"pullStrategy"`
 }
 
+// SubmodulesRecursive clones and updates submodules recursively.
+const SubmodulesRecursive = "recursive"
+
 // TaskConfig contains all the data in a YAML task config file.
 type TaskConfig struct {
 	Name      string           `json:"name"`
+	DependsOn []string         `json:"dependsOn" yaml:"dependsOn"`
 	Variables []VariableConfig `json:"variables"`
 	Steps     []StepConfig     `json:"tasks"`
 }
@@ -63,8 +82,61 @@ type VariableConfig struct {
 
 // StepConfig contains all the data in a YAML step config file.
 type StepConfig struct {
-	Projects []string `json:"projects"`
-	Commands []string `json:"commands"`
+	Projects    []string        `json:"projects"`
+	Commands    []CommandConfig `json:"commands"`
+	Parallel    bool            `json:"parallel"`
+	MaxParallel int             `json:"maxParallel" yaml:"maxParallel"`
+}
+
+// CommandConfig contains all the data in a YAML command config file.
+// In YAML it can either be a string containing the command or an object.
+// A string starting with "spawn " is a shorthand for a background command.
+type CommandConfig struct {
+	Command         string            `json:"command"`
+	Retries         int               `json:"retries"`
+	RetryDelay      time.Duration     `json:"retryDelay" yaml:"retryDelay"`
+	Timeout         time.Duration     `json:"timeout"`
+	ContinueOnError bool              `json:"continueOnError" yaml:"continueOnError"`
+	Env             map[string]string `json:"env"`
+	Background      bool              `json:"background"`
+	Name            string            `json:"name"`
+	StopSignal      string            `json:"stopSignal" yaml:"stopSignal"`
+	StopTimeout     time.Duration     `json:"stopTimeout" yaml:"stopTimeout"`
+	KillTimeout     time.Duration     `json:"killTimeout" yaml:"killTimeout"`
+	Restart         string            `json:"restart"`
+	MaxRestarts     int               `json:"maxRestarts" yaml:"maxRestarts"`
+	RestartDelay    time.Duration     `json:"restartDelay" yaml:"restartDelay"`
+	Ready           *ReadyConfig      `json:"ready"`
+	WaitReady       bool              `json:"waitReady" yaml:"waitReady"`
+	TTY             bool              `json:"tty"`
+}
+
+// ReadyConfig contains all the data in a YAML readiness probe config file.
+// Only one of TCP, HTTP and Log must be set.
+// TCP can be a port number, in which case the host is localhost.
+type ReadyConfig struct {
+	TCP      string        `json:"tcp"`
+	HTTP     string        `json:"http"`
+	Log      string        `json:"log"`
+	Interval time.Duration `json:"interval"`
+	Timeout  time.Duration `json:"timeout"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *CommandConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal(&c.Command); err == nil {
+		if strings.HasPrefix(c.Command, "spawn ") {
+			c.Command = strings.TrimSpace(strings.TrimPrefix(c.Command, "spawn "))
+			c.Background = true
+		}
+
+		return nil
+	}
+
+	// Use a different type to avoid infinite recursion.
+	type commandConfig CommandConfig
+
+	return unmarshal((*commandConfig)(c))
 }
 
 // UpsertNodes upserts nodes for the content of the config.
@@ -76,7 +148,7 @@ func (c WorkspacesConfig) UpsertNodes(
 	var workspaceIDs []string
 
 	for _, workspaceConfig := range c.Workspaces {
-		id, err := workspaceConfig.UpsertNodes(nodes, subs)
+		id, err := workspaceConfig.UpsertNodes(nodes, subs, c.Filename)
 		if err != nil {
 			return nil, err
 		}
@@ -88,10 +160,12 @@ func (c WorkspacesConfig) UpsertNodes(
 }
 
 // UpsertNodes upserts nodes for the content of the config.
+// The filename is the file the config was loaded from.
 // It returns the ID of the workspace upserted.
 func (c WorkspaceConfig) UpsertNodes(
 	nodes *NodeManager,
 	subs *pubsub.PubSub,
+	filename string,
 ) (string, error) {
 	id := relay.EncodeID(NodeTypeWorkspace, c.Slug)
 
@@ -100,18 +174,40 @@ func (c WorkspaceConfig) UpsertNodes(
 		workspace.Name = c.Name
 		workspace.Description = c.Description
 		workspace.Notes = c.Notes
+		workspace.Credentials = c.Credentials
+		workspace.ConfigFilename = filename
 		workspace.ProjectIDs = nil
 		workspace.TaskIDs = nil
 		projectSlugToID := map[string]string{}
+		taskNameToID := map[string]string{}
+
+		if err := c.checkTaskDependencies(); err != nil {
+			return err
+		}
 
 		for _, projectConfig := range c.Projects {
-			projectID := projectConfig.UpsertNodes(nodes, subs, id, c.Slug)
+			projectID, err := projectConfig.UpsertNodes(nodes, subs, id, c.Slug)
+			if err != nil {
+				return err
+			}
+
 			workspace.ProjectIDs = append(workspace.ProjectIDs, projectID)
 			projectSlugToID[projectConfig.Slug] = projectID
 		}
 
 		for _, taskConfig := range c.Tasks {
-			taskID, err := taskConfig.UpsertNodes(nodes, subs, id, workspace.Slug, projectSlugToID)
+			taskNameToID[taskConfig.Name] = relay.EncodeID(NodeTypeTask, c.Slug, taskConfig.Name)
+		}
+
+		for _, taskConfig := range c.Tasks {
+			taskID, err := taskConfig.UpsertNodes(
+				nodes,
+				subs,
+				id,
+				workspace.Slug,
+				projectSlugToID,
+				taskNameToID,
+			)
 			if err != nil {
 				return err
 			}
@@ -131,6 +227,34 @@ func (c WorkspaceConfig) UpsertNodes(
 	return id, nil
 }
 
+// checkTaskDependencies makes sure that task dependencies exist and don't form a cycle.
+func (c WorkspaceConfig) checkTaskDependencies() error {
+	dependencies := map[string][]string{}
+
+	for _, taskConfig := range c.Tasks {
+		dependencies[taskConfig.Name] = taskConfig.DependsOn
+	}
+
+	for _, taskConfig := range c.Tasks {
+		for _, name := range taskConfig.DependsOn {
+			if _, ok := dependencies[name]; !ok {
+				return DependencyError{Task: taskConfig.Name, Dependency: name}
+			}
+		}
+	}
+
+	for _, taskConfig := range c.Tasks {
+		_, err := sortDependencies(taskConfig.Name, func(name string) ([]string, error) {
+			return dependencies[name], nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // UpsertNodes upserts nodes for the content of the config.
 // It returns the ID of the project upserted.
 func (c ProjectConfig) UpsertNodes(
@@ -138,7 +262,24 @@ func (c ProjectConfig) UpsertNodes(
 	subs *pubsub.PubSub,
 	workspaceID string,
 	workspaceSlug string,
-) string {
+) (string, error) {
+	if c.Submodules != "" && c.Submodules != SubmodulesRecursive {
+		return "", ErrSubmodules
+	}
+
+	if c.Depth < 0 {
+		return "", ErrDepthNegative
+	}
+
+	if len(c.SparsePaths) > 0 {
+		return "", ErrSparsePaths
+	}
+
+	pullStrategy, err := ParsePullStrategy(c.PullStrategy)
+	if err != nil {
+		return "", err
+	}
+
 	id := relay.EncodeID(
 		NodeTypeProject,
 		workspaceSlug,
@@ -150,13 +291,16 @@ func (c ProjectConfig) UpsertNodes(
 		project.Repository = c.Repository
 		project.Branch = c.Branch
 		project.Description = c.Description
+		project.Depth = c.Depth
+		project.RecurseSubmodules = c.Submodules == SubmodulesRecursive
+		project.PullStrategy = pullStrategy
 		project.WorkspaceID = workspaceID
 
 		nodes.MustStoreProject(project)
 		subs.Publish(ProjectUpserted, id)
 	})
 
-	return id
+	return id, nil
 }
 
 // UpsertNodes upserts nodes for the content of the config.
@@ -167,6 +311,7 @@ func (c TaskConfig) UpsertNodes(
 	workspaceID string,
 	workspaceSlug string,
 	projectSlugToID map[string]string,
+	taskNameToID map[string]string,
 ) (string, error) {
 	id := relay.EncodeID(
 		NodeTypeTask,
@@ -177,9 +322,18 @@ func (c TaskConfig) UpsertNodes(
 	err := nodes.MustLockOrNewTaskE(id, func(task Task) error {
 		task.Name = c.Name
 		task.WorkspaceID = workspaceID
+		task.DependencyIDs = nil
 		task.VariableIDs = nil
 		task.StepIDs = nil
 
+		for _, name := range c.DependsOn {
+			dependencyID, ok := taskNameToID[name]
+			if !ok {
+				return DependencyError{Task: c.Name, Dependency: name}
+			}
+			task.DependencyIDs = append(task.DependencyIDs, dependencyID)
+		}
+
 		for variableIndex, variableConfig := range c.Variables {
 			variableID := variableConfig.UpsertNodes(
 				nodes,
@@ -265,6 +419,8 @@ func (c StepConfig) UpsertNodes(
 
 	err := nodes.MustLockOrNewStepE(id, func(step Step) error {
 		step.TaskID = taskID
+		step.Parallel = c.Parallel
+		step.MaxParallel = c.MaxParallel
 		step.ProjectIDs = nil
 		step.CommandIDs = nil
 
@@ -276,7 +432,7 @@ func (c StepConfig) UpsertNodes(
 			step.ProjectIDs = append(step.ProjectIDs, id)
 		}
 
-		for commandIndex, command := range c.Commands {
+		for commandIndex, commandConfig := range c.Commands {
 			id := relay.EncodeID(
 				NodeTypeCommand,
 				workspaceSlug,
@@ -284,10 +440,11 @@ func (c StepConfig) UpsertNodes(
 				fmt.Sprint(stepIndex),
 				fmt.Sprint(commandIndex),
 			)
-			nodes.MustStoreCommand(Command{
-				ID:      id,
-				Command: command,
-			})
+			command, err := commandConfig.command(id)
+			if err != nil {
+				return err
+			}
+			nodes.MustStoreCommand(command)
 			step.CommandIDs = append(step.CommandIDs, id)
 		}
 
@@ -302,6 +459,118 @@ func (c StepConfig) UpsertNodes(
 	return id, nil
 }
 
+// command creates a Command node for the content of the config.
+func (c CommandConfig) command(id string) (Command, error) {
+	var env []string
+
+	for name, value := range c.Env {
+		env = append(env, fmt.Sprintf("%s=%s", name, value))
+	}
+
+	sort.Strings(env)
+
+	stopSignal := c.StopSignal
+	if stopSignal == "" {
+		stopSignal = DefaultStopSignal
+	}
+
+	if _, err := ParseSignal(stopSignal); err != nil {
+		return Command{}, err
+	}
+
+	stopTimeout := c.StopTimeout
+	if stopTimeout <= 0 {
+		stopTimeout = DefaultStopTimeout
+	}
+
+	killTimeout := c.KillTimeout
+	if killTimeout <= 0 {
+		killTimeout = DefaultKillTimeout
+	}
+
+	restartPolicy, err := ParseRestartPolicy(c.Restart)
+	if err != nil {
+		return Command{}, err
+	}
+
+	var readinessProbe *ReadinessProbe
+
+	if c.Ready != nil {
+		probe, err := c.Ready.readinessProbe()
+		if err != nil {
+			return Command{}, err
+		}
+
+		readinessProbe = &probe
+	}
+
+	return Command{
+		ID:              id,
+		Command:         c.Command,
+		Retries:         c.Retries,
+		RetryDelay:      Duration(c.RetryDelay),
+		Timeout:         Duration(c.Timeout),
+		ContinueOnError: c.ContinueOnError,
+		Env:             env,
+		Background:      c.Background,
+		Name:            c.Name,
+		StopSignal:      stopSignal,
+		StopTimeout:     Duration(stopTimeout),
+		KillTimeout:     Duration(killTimeout),
+		RestartPolicy:   restartPolicy,
+		MaxRestarts:     c.MaxRestarts,
+		RestartDelay:    Duration(c.RestartDelay),
+		ReadinessProbe:  readinessProbe,
+		WaitReady:       c.WaitReady,
+		TTY:             c.TTY,
+	}, nil
+}
+
+// readinessProbe creates a ReadinessProbe for the content of the config.
+func (c ReadyConfig) readinessProbe() (ReadinessProbe, error) {
+	probe := ReadinessProbe{
+		Interval: Duration(c.Interval),
+		Timeout:  Duration(c.Timeout),
+	}
+
+	if probe.Interval <= 0 {
+		probe.Interval = Duration(DefaultReadinessProbeInterval)
+	}
+
+	if probe.Timeout <= 0 {
+		probe.Timeout = Duration(DefaultReadinessProbeTimeout)
+	}
+
+	count := 0
+
+	if c.TCP != "" {
+		address := c.TCP
+		if !strings.Contains(address, ":") {
+			address = "localhost:" + address
+		}
+		probe.TCP = &address
+		count++
+	}
+
+	if c.HTTP != "" {
+		probe.HTTP = &c.HTTP
+		count++
+	}
+
+	if c.Log != "" {
+		probe.Log = &c.Log
+		count++
+	}
+
+	if count != 1 {
+		return probe, ErrProbe
+	}
+
+	_, err := probe.LogRegexp()
+
+	return probe, err
+}
+
 // LoadWorkspacesConfigYAML loads a config from a YAML file.
 func LoadWorkspacesConfigYAML(filename string) (WorkspacesConfig, error) {
 	config := WorkspacesConfig{
@@ -0,0 +1,42 @@
+// Copyright 2019 Stratumn
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"groundcontrol/pubsub"
+)
+
+func TestProjectConfig_UpsertNodes(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  ProjectConfig
+		wantErr error
+	}{
+		{"valid", ProjectConfig{Slug: "a", Depth: 1, Submodules: SubmodulesRecursive}, nil},
+		{"submodules", ProjectConfig{Slug: "a", Submodules: "all"}, ErrSubmodules},
+		{"negative depth", ProjectConfig{Slug: "a", Depth: -1}, ErrDepthNegative},
+		{"sparse paths", ProjectConfig{Slug: "a", SparsePaths: []string{"docs"}}, ErrSparsePaths},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.config.UpsertNodes(&NodeManager{}, pubsub.New(10), "workspace", "workspace")
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
@@ -16,99 +16,262 @@ package queue
 
 import (
 	"context"
+	"errors"
 	"sync"
 )
 
-// Queue is a simple queue with concurency support.
+// Errors.
+var (
+	ErrNotFound = errors.New("job isn't in the queue")
+)
+
+// Queue is a priority queue with concurrency support.
+//
+// Jobs are identified by an ID so they can be inspected, removed or moved
+// while they are waiting. Jobs can belong to pools which limit how many of
+// their jobs run concurrently. A job waiting for a pool doesn't prevent the
+// jobs behind it from starting.
 type Queue struct {
-	ch          chan func()
-	hiCh        chan func()
-	concurrency int
+	mu     sync.Mutex
+	jobs   []*job
+	notify chan struct{}
+
+	limits  map[string]int
+	running map[string]int
 }
 
-// New creates a queue with given concurrency.
-func New(concurrency int) *Queue {
+type job struct {
+	id    string
+	hi    bool
+	fn    func()
+	pools []string
+}
+
+// New creates a queue. Jobs that don't belong to a pool with a limit start as
+// soon as they reach the front of the queue.
+func New() *Queue {
 	return &Queue{
-		ch:          make(chan func()),
-		hiCh:        make(chan func()),
-		concurrency: concurrency,
+		notify:  make(chan struct{}, 1),
+		limits:  map[string]int{},
+		running: map[string]int{},
 	}
 }
 
 // Work tells the queue to start executing jobs.
 //
-// It blocks until the context is canceled.
+// It blocks until the context is canceled and the running jobs returned.
 func (q *Queue) Work(ctx context.Context) error {
 	wg := sync.WaitGroup{}
-	wg.Add(q.concurrency)
-
-	for i := 0; i < q.concurrency; i++ {
-		go func() {
-			defer wg.Done()
-
-			for {
-				select {
-				case <-ctx.Done():
-					return
-				case job := <-q.hiCh:
-					job()
-					continue
-				default:
-				}
-
-				select {
-				case <-ctx.Done():
-					return
-				case job := <-q.hiCh:
-					job()
-				case job := <-q.ch:
-					job()
-				}
-			}
-		}()
+
+	for {
+		for j := q.pop(); j != nil; j = q.pop() {
+			wg.Add(1)
+
+			go func(j *job) {
+				defer wg.Done()
+				j.fn()
+				q.release(j)
+			}(j)
+		}
+
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return ctx.Err()
+		case <-q.notify:
+		}
+	}
+}
+
+// SetLimit sets how many jobs of a pool can run concurrently. A limit of zero
+// removes the limit. Lowering a limit doesn't stop running jobs.
+func (q *Queue) SetLimit(pool string, limit int) {
+	q.mu.Lock()
+
+	if limit > 0 {
+		q.limits[pool] = limit
+	} else {
+		delete(q.limits, pool)
+	}
+
+	q.mu.Unlock()
+
+	q.signal()
+}
+
+// Limit returns how many jobs of a pool can run concurrently, or zero if the
+// pool doesn't have a limit.
+func (q *Queue) Limit(pool string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.limits[pool]
+}
+
+// Running returns how many jobs of a pool are running.
+func (q *Queue) Running(pool string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.running[pool]
+}
+
+// Push puts a job at the end of the queue, or after the other hi-priority
+// jobs if hi is true. The job counts towards the limits of the given pools.
+func (q *Queue) Push(id string, hi bool, fn func(), pools ...string) {
+	q.mu.Lock()
+
+	position := len(q.jobs)
+
+	if hi {
+		position = 0
+		for position < len(q.jobs) && q.jobs[position].hi {
+			position++
+		}
+	}
+
+	q.insert(position, &job{id: id, hi: hi, fn: fn, pools: pools})
+	q.mu.Unlock()
+
+	q.signal()
+}
+
+// Remove removes a job that hasn't started yet from the queue.
+func (q *Queue) Remove(id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	i := q.index(id)
+	if i < 0 {
+		return ErrNotFound
+	}
+
+	q.jobs = append(q.jobs[:i], q.jobs[i+1:]...)
+
+	return nil
+}
+
+// Move moves a job that hasn't started yet to the given position.
+// Position zero is the next job to start. Positions out of range are clamped.
+func (q *Queue) Move(id string, position int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	i := q.index(id)
+	if i < 0 {
+		return ErrNotFound
+	}
+
+	j := q.jobs[i]
+	q.jobs = append(q.jobs[:i], q.jobs[i+1:]...)
+
+	if position < 0 {
+		position = 0
+	} else if position > len(q.jobs) {
+		position = len(q.jobs)
+	}
+
+	q.insert(position, j)
+
+	return nil
+}
+
+// Position returns the position of a job that hasn't started yet.
+func (q *Queue) Position(id string) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	i := q.index(id)
+	if i < 0 {
+		return 0, ErrNotFound
+	}
+
+	return i, nil
+}
+
+// IDs returns the IDs of the jobs that haven't started yet in order.
+func (q *Queue) IDs() []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	ids := make([]string, len(q.jobs))
+	for i, j := range q.jobs {
+		ids[i] = j.id
 	}
 
-	wg.Wait()
-	return ctx.Err()
+	return ids
 }
 
-// Do puts a job at the end of the queue and blocks until executed.
-func (q *Queue) Do(job func()) {
-	done := make(chan struct{})
-	q.ch <- func() {
-		job()
-		close(done)
+// pop removes the first job whose pools aren't full from the queue and marks
+// it as running.
+func (q *Queue) pop() *job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, j := range q.jobs {
+		if !q.available(j) {
+			continue
+		}
+
+		q.jobs = append(q.jobs[:i], q.jobs[i+1:]...)
+
+		for _, pool := range j.pools {
+			q.running[pool]++
+		}
+
+		return j
 	}
-	<-done
+
+	return nil
 }
 
-// DoError puts a job that can return an error at the end of the queue and
-// blocks until executed.
-func (q *Queue) DoError(job func() error) error {
-	done := make(chan error, 1)
-	q.ch <- func() {
-		done <- job()
+// release marks a job as no longer running.
+func (q *Queue) release(j *job) {
+	q.mu.Lock()
+
+	for _, pool := range j.pools {
+		q.running[pool]--
+
+		if q.running[pool] == 0 {
+			delete(q.running, pool)
+		}
 	}
-	return <-done
+
+	q.mu.Unlock()
+
+	q.signal()
+}
+
+// available returns whether none of the pools of a job are full.
+func (q *Queue) available(j *job) bool {
+	for _, pool := range j.pools {
+		if limit, ok := q.limits[pool]; ok && q.running[pool] >= limit {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (q *Queue) insert(position int, j *job) {
+	q.jobs = append(q.jobs, nil)
+	copy(q.jobs[position+1:], q.jobs[position:])
+	q.jobs[position] = j
 }
 
-// DoHi puts a task at the end of the hi-priority queue and blocks until
-// executed.
-func (q *Queue) DoHi(task func()) {
-	done := make(chan struct{})
-	q.hiCh <- func() {
-		task()
-		close(done)
+func (q *Queue) index(id string) int {
+	for i, j := range q.jobs {
+		if j.id == id {
+			return i
+		}
 	}
-	<-done
+
+	return -1
 }
 
-// DoErrorHi puts a task that can return an error at the end of the hi-priority
-// queue and blocks until executed.
-func (q *Queue) DoErrorHi(task func() error) error {
-	done := make(chan error, 1)
-	q.hiCh <- func() {
-		done <- task()
+func (q *Queue) signal() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
 	}
-	return <-done
 }
@@ -0,0 +1,168 @@
+// Copyright 2019 Stratumn
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueue_Push(t *testing.T) {
+	q := New()
+
+	q.Push("a", false, func() {})
+	q.Push("b", false, func() {})
+	q.Push("c", true, func() {})
+	q.Push("d", true, func() {})
+
+	assert.Equal(t, []string{"c", "d", "a", "b"}, q.IDs())
+}
+
+func TestQueue_Move(t *testing.T) {
+	q := New()
+
+	for _, id := range []string{"a", "b", "c"} {
+		q.Push(id, false, func() {})
+	}
+
+	assert.NoError(t, q.Move("c", 0))
+	assert.Equal(t, []string{"c", "a", "b"}, q.IDs())
+
+	assert.NoError(t, q.Move("c", 10))
+	assert.Equal(t, []string{"a", "b", "c"}, q.IDs())
+
+	position, err := q.Position("b")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, position)
+
+	assert.Equal(t, ErrNotFound, q.Move("d", 0))
+}
+
+func TestQueue_Remove(t *testing.T) {
+	q := New()
+
+	q.Push("a", false, func() {})
+	q.Push("b", false, func() {})
+
+	assert.NoError(t, q.Remove("a"))
+	assert.Equal(t, []string{"b"}, q.IDs())
+
+	_, err := q.Position("a")
+	assert.Equal(t, ErrNotFound, err)
+	assert.Equal(t, ErrNotFound, q.Remove("a"))
+}
+
+func TestQueue_Work(t *testing.T) {
+	q := New()
+	done := make(chan string, 3)
+
+	q.SetLimit("pool", 1)
+
+	for _, id := range []string{"a", "b", "c"} {
+		id := id
+		q.Push(id, false, func() { done <- id }, "pool")
+	}
+
+	assert.NoError(t, q.Move("c", 0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go q.Work(ctx)
+
+	var order []string
+
+	for i := 0; i < 3; i++ {
+		select {
+		case id := <-done:
+			order = append(order, id)
+		case <-time.After(time.Second):
+			t.Fatal("timeout")
+		}
+	}
+
+	assert.Equal(t, []string{"c", "a", "b"}, order)
+	assert.Empty(t, q.IDs())
+}
+
+func TestQueue_Work_pools(t *testing.T) {
+	q := New()
+	q.SetLimit("git", 2)
+	q.SetLimit("host", 1)
+	q.SetLimit("run", 1)
+
+	started := make(chan string, 4)
+	release := make(chan struct{})
+
+	push := func(id string, pools ...string) {
+		q.Push(id, false, func() {
+			started <- id
+			<-release
+		}, pools...)
+	}
+
+	push("run1", "run")
+	push("run2", "run")
+	push("git1", "git", "host")
+	push("git2", "git", "host")
+	push("git3", "git")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go q.Work(ctx)
+
+	var ids []string
+
+	for i := 0; i < 3; i++ {
+		select {
+		case id := <-started:
+			ids = append(ids, id)
+		case <-time.After(time.Second):
+			t.Fatal("timeout")
+		}
+	}
+
+	assert.ElementsMatch(t, []string{"run1", "git1", "git3"}, ids)
+	assert.Equal(t, []string{"run2", "git2"}, q.IDs())
+	assert.Equal(t, 2, q.Running("git"))
+
+	select {
+	case id := <-started:
+		t.Fatalf("%s started before a job finished", id)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	q.SetLimit("run", 2)
+
+	select {
+	case id := <-started:
+		assert.Equal(t, "run2", id)
+	case <-time.After(time.Second):
+		t.Fatal("timeout")
+	}
+
+	close(release)
+
+	select {
+	case id := <-started:
+		assert.Equal(t, "git2", id)
+	case <-time.After(time.Second):
+		t.Fatal("timeout")
+	}
+}
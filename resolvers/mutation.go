@@ -14,6 +14,70 @@
 
 package resolvers
 
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"groundcontrol/models"
+)
+
 type mutationResolver struct {
 	*Resolver
 }
+
+// addWorkspaceProjectJobs adds a job for each of the given projects of a workspace.
+// If no project IDs are given, all the cloned projects of the workspace are used.
+// The projects are validated before any job is added. If adding the job of a
+// project fails, the error is added to the response and the jobs of the other
+// projects are still returned.
+func addWorkspaceProjectJobs(
+	ctx context.Context,
+	workspaceID string,
+	projectIDs []string,
+	add func(projectID string) (string, error),
+) ([]models.Job, error) {
+	nodes := models.GetModelContext(ctx).Nodes
+
+	workspace, err := nodes.LoadWorkspace(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if projectIDs == nil {
+		for _, projectID := range workspace.ProjectIDs {
+			if nodes.MustLoadProject(projectID).IsCloned(ctx) {
+				projectIDs = append(projectIDs, projectID)
+			}
+		}
+	}
+
+	var projects []models.Project
+
+	for _, projectID := range projectIDs {
+		project, err := nodes.LoadProject(projectID)
+		if err != nil {
+			return nil, err
+		}
+
+		if project.WorkspaceID != workspaceID {
+			return nil, models.ErrNotFound
+		}
+
+		projects = append(projects, project)
+	}
+
+	var slice []models.Job
+
+	for _, project := range projects {
+		jobID, err := add(project.ID)
+		if err != nil {
+			graphql.AddErrorf(ctx, "project %s: %s", project.Slug, err.Error())
+			continue
+		}
+
+		slice = append(slice, nodes.MustLoadJob(jobID))
+	}
+
+	return slice, nil
+}
@@ -21,10 +21,10 @@ import (
 	"groundcontrol/models"
 )
 
-func (r *mutationResolver) PullProject(ctx context.Context, id string) (models.Job, error) {
+func (r *mutationResolver) PullProject(ctx context.Context, id string, strategy *models.PullStrategy) (models.Job, error) {
 	nodes := models.GetModelContext(ctx).Nodes
 
-	jobID, err := jobs.Pull(ctx, id, models.JobPriorityHigh)
+	jobID, err := jobs.Pull(ctx, id, strategy, models.JobPriorityHigh)
 	if err != nil {
 		return models.Job{}, err
 	}
@@ -21,7 +21,7 @@ import (
 	"groundcontrol/models"
 )
 
-func (r *mutationResolver) PullWorkspace(ctx context.Context, id string) ([]models.Job, error) {
+func (r *mutationResolver) PullWorkspace(ctx context.Context, id string, strategy *models.PullStrategy) ([]models.Job, error) {
 	nodes := models.GetModelContext(ctx).Nodes
 
 	workspace, err := nodes.LoadWorkspace(id)
@@ -38,7 +38,7 @@ func (r *mutationResolver) PullWorkspace(ctx context.Context, id string) ([]mode
 			continue
 		}
 
-		jobID, err := jobs.Pull(ctx, project.ID, models.JobPriorityHigh)
+		jobID, err := jobs.Pull(ctx, project.ID, strategy, models.JobPriorityHigh)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,39 @@
+// Copyright 2019 Stratumn
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolvers
+
+import (
+	"context"
+
+	"groundcontrol/jobs"
+	"groundcontrol/models"
+)
+
+func (r *mutationResolver) RestoreWorkspaceSnapshot(ctx context.Context, id string) ([]models.Job, error) {
+	nodes := models.GetModelContext(ctx).Nodes
+
+	jobIDs, err := jobs.RestoreSnapshot(ctx, id, models.JobPriorityHigh)
+	if err != nil {
+		return nil, err
+	}
+
+	var slice []models.Job
+
+	for _, jobID := range jobIDs {
+		slice = append(slice, nodes.MustLoadJob(jobID))
+	}
+
+	return slice, nil
+}
@@ -33,7 +33,7 @@ func (r *mutationResolver) StartProcessGroup(ctx context.Context, id string) (mo
 	for _, processID := range processGroup.ProcessIDs {
 		process := nodes.MustLoadProcess(processID)
 
-		if process.Status == models.ProcessStatusRunning {
+		if process.IsStarted() {
 			continue
 		}
 
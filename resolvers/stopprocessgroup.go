@@ -33,7 +33,7 @@ func (r *mutationResolver) StopProcessGroup(ctx context.Context, id string) (mod
 	for _, processID := range processGroup.ProcessIDs {
 		process := nodes.MustLoadProcess(processID)
 
-		if process.Status != models.ProcessStatusRunning {
+		if !process.IsStarted() {
 			continue
 		}
 
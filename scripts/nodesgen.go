@@ -156,6 +156,7 @@ func (n *NodeManager) Lock{{$type}}(id string, fn func({{$type}})) error {
 
 	node, err := n.Load{{$type}}(id)
 	if err != nil {
+		n.Unlock(id)
 		return err
 	}
 
@@ -171,6 +172,7 @@ func (n *NodeManager) Lock{{$type}}E(id string, fn func({{$type}}) error) error
 
 	node, err := n.Load{{$type}}(id)
 	if err != nil {
+		n.Unlock(id)
 		return err
 	}
 
@@ -218,6 +220,7 @@ func (n *NodeManager) LockOrNew{{$type}}(id string, fn func({{$type}})) error {
 			ID: id,
 		}
 	} else if err != nil {
+		n.Unlock(id)
 		return err
 	}
 
@@ -237,6 +240,7 @@ func (n *NodeManager) LockOrNew{{$type}}E(id string, fn func({{$type}}) error) e
 			ID: id,
 		}
 	} else if err != nil {
+		n.Unlock(id)
 		return err
 	}
 
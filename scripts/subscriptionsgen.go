@@ -171,8 +171,13 @@ func (r *subscriptionResolver) {{$type}}Upserted(ctx context.Context, id *string
 		if id != nil && *id != nodeID {
 			return
 		}
+		// The node could have been deleted since the message was published.
+		node, err := r.Nodes.Load{{$type}}(nodeID)
+		if err != nil {
+			return
+		}
 		select {
-		case ch <- r.Nodes.MustLoad{{$type}}(nodeID):
+		case ch <- node:
 		default:
 		}
 	})